@@ -0,0 +1,333 @@
+// Code generated by cmd/nodegen. DO NOT EDIT.
+
+package c
+
+import "fmt"
+
+// EqualOptions Equalで比べずに無視する部分
+type EqualOptions struct {
+	// 位置を比べない
+	IgnoreSpan bool
+	// 型を比べない
+	IgnoreTType bool
+}
+
+// Clone 部分木を深く複製する
+func (n *Node) Clone() *Node {
+	if n == nil {
+		return nil
+	}
+	return &Node{NodeKind: n.NodeKind, Field: cloneField(n.Field), Span: n.Span}
+}
+
+func cloneNodes(nodes []*Node) []*Node {
+	if nodes == nil {
+		return nil
+	}
+	cloned := make([]*Node, len(nodes))
+	for i, node := range nodes {
+		cloned[i] = node.Clone()
+	}
+	return cloned
+}
+
+func cloneTType(tt TType) TType {
+	switch tt := tt.(type) {
+	case TTuple:
+		cloned := make(TTuple, len(tt))
+		for i, t := range tt {
+			cloned[i] = cloneTType(t)
+		}
+		return cloned
+	default:
+		return tt
+	}
+}
+
+func cloneField(field Field) Field {
+	switch f := field.(type) {
+	case nil:
+		return nil
+	case *VariableDeclareField:
+		return &VariableDeclareField{
+			TType: cloneTType(f.TType),
+			Ident: f.Ident.Clone(),
+		}
+	case *FunctionDeclareField:
+		return &FunctionDeclareField{
+			TType:  cloneTType(f.TType),
+			Ident:  f.Ident.Clone(),
+			Params: f.Params.Clone(),
+		}
+	case *VariableDefineField:
+		return &VariableDefineField{
+			TType: cloneTType(f.TType),
+			Ident: f.Ident.Clone(),
+			Value: f.Value.Clone(),
+		}
+	case *FunctionDefineField:
+		return &FunctionDefineField{
+			TType:  cloneTType(f.TType),
+			Ident:  f.Ident.Clone(),
+			Params: f.Params.Clone(),
+			Block:  f.Block.Clone(),
+		}
+	case *TypeDefineField:
+		return &TypeDefineField{
+			TType: cloneTType(f.TType),
+			Ident: f.Ident.Clone(),
+		}
+	case *ParamField:
+		return &ParamField{
+			TType:   cloneTType(f.TType),
+			Ident:   f.Ident.Clone(),
+			Default: f.Default.Clone(),
+		}
+	case *BlockField:
+		return &BlockField{
+			Stmts: cloneNodes(f.Stmts),
+		}
+	case *IfElseField:
+		return &IfElseField{
+			Cond:      f.Cond.Clone(),
+			IfBlock:   f.IfBlock.Clone(),
+			ElseBlock: f.ElseBlock.Clone(),
+		}
+	case *WhileField:
+		return &WhileField{
+			Cond:  f.Cond.Clone(),
+			Block: f.Block.Clone(),
+			Label: f.Label,
+		}
+	case *ForField:
+		return &ForField{
+			Init:  f.Init.Clone(),
+			Cond:  f.Cond.Clone(),
+			Loop:  f.Loop.Clone(),
+			Block: f.Block.Clone(),
+			Label: f.Label,
+		}
+	case *AssignField:
+		return &AssignField{
+			To:    f.To.Clone(),
+			Value: f.Value.Clone(),
+		}
+	case *BinaryField:
+		return &BinaryField{
+			TType:     cloneTType(f.TType),
+			Operation: f.Operation,
+			LHS:       f.LHS.Clone(),
+			RHS:       f.RHS.Clone(),
+		}
+	case *LiteralField:
+		return &LiteralField{
+			TType: cloneTType(f.TType),
+			I:     f.I,
+			F:     f.F,
+			S:     f.S,
+		}
+	case *NotField:
+		return &NotField{
+			Value: f.Value.Clone(),
+		}
+	case *UnaryField:
+		return &UnaryField{
+			TType:     cloneTType(f.TType),
+			Operation: f.Operation,
+			Value:     f.Value.Clone(),
+		}
+	case *MultipleField:
+		return &MultipleField{
+			TType:  cloneTType(f.TType),
+			Values: cloneNodes(f.Values),
+		}
+	case *ReturnField:
+		return &ReturnField{
+			TType: cloneTType(f.TType),
+			Value: f.Value.Clone(),
+		}
+	case *BreakField:
+		return &BreakField{
+			Label: f.Label,
+		}
+	case *ContinueField:
+		return &ContinueField{
+			Label: f.Label,
+		}
+	case *CallField:
+		return &CallField{
+			TType: cloneTType(f.TType),
+			Ident: f.Ident.Clone(),
+			Args:  f.Args.Clone(),
+		}
+	case *IdentField:
+		return &IdentField{
+			TType: cloneTType(f.TType),
+			S:     f.S,
+		}
+	default:
+		panic(fmt.Sprintf("unexpected field: %T", field))
+	}
+}
+
+// Equal 二つの部分木が同じ形か
+func Equal(a, b *Node, opts EqualOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.NodeKind != b.NodeKind {
+		return false
+	}
+	if !opts.IgnoreSpan && a.Span != b.Span {
+		return false
+	}
+	return equalField(a.Field, b.Field, opts)
+}
+
+func equalNodes(a, b []*Node, opts EqualOptions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i], opts) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalTType(a, b TType, opts EqualOptions) bool {
+	if opts.IgnoreTType {
+		return true
+	}
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.IsEqual(b)
+}
+
+func equalField(a, b Field, opts EqualOptions) bool {
+	switch fa := a.(type) {
+	case nil:
+		return b == nil
+	case *VariableDeclareField:
+		fb, ok := b.(*VariableDeclareField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts)
+	case *FunctionDeclareField:
+		fb, ok := b.(*FunctionDeclareField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts) &&
+			Equal(fa.Params, fb.Params, opts)
+	case *VariableDefineField:
+		fb, ok := b.(*VariableDefineField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts) &&
+			Equal(fa.Value, fb.Value, opts)
+	case *FunctionDefineField:
+		fb, ok := b.(*FunctionDefineField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts) &&
+			Equal(fa.Params, fb.Params, opts) &&
+			Equal(fa.Block, fb.Block, opts)
+	case *TypeDefineField:
+		fb, ok := b.(*TypeDefineField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts)
+	case *ParamField:
+		fb, ok := b.(*ParamField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts) &&
+			Equal(fa.Default, fb.Default, opts)
+	case *BlockField:
+		fb, ok := b.(*BlockField)
+		return ok &&
+			equalNodes(fa.Stmts, fb.Stmts, opts)
+	case *IfElseField:
+		fb, ok := b.(*IfElseField)
+		return ok &&
+			Equal(fa.Cond, fb.Cond, opts) &&
+			Equal(fa.IfBlock, fb.IfBlock, opts) &&
+			Equal(fa.ElseBlock, fb.ElseBlock, opts)
+	case *WhileField:
+		fb, ok := b.(*WhileField)
+		return ok &&
+			Equal(fa.Cond, fb.Cond, opts) &&
+			Equal(fa.Block, fb.Block, opts) &&
+			fa.Label == fb.Label
+	case *ForField:
+		fb, ok := b.(*ForField)
+		return ok &&
+			Equal(fa.Init, fb.Init, opts) &&
+			Equal(fa.Cond, fb.Cond, opts) &&
+			Equal(fa.Loop, fb.Loop, opts) &&
+			Equal(fa.Block, fb.Block, opts) &&
+			fa.Label == fb.Label
+	case *AssignField:
+		fb, ok := b.(*AssignField)
+		return ok &&
+			Equal(fa.To, fb.To, opts) &&
+			Equal(fa.Value, fb.Value, opts)
+	case *BinaryField:
+		fb, ok := b.(*BinaryField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			fa.Operation == fb.Operation &&
+			Equal(fa.LHS, fb.LHS, opts) &&
+			Equal(fa.RHS, fb.RHS, opts)
+	case *LiteralField:
+		fb, ok := b.(*LiteralField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			fa.I == fb.I &&
+			fa.F == fb.F &&
+			fa.S == fb.S
+	case *NotField:
+		fb, ok := b.(*NotField)
+		return ok &&
+			Equal(fa.Value, fb.Value, opts)
+	case *UnaryField:
+		fb, ok := b.(*UnaryField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			fa.Operation == fb.Operation &&
+			Equal(fa.Value, fb.Value, opts)
+	case *MultipleField:
+		fb, ok := b.(*MultipleField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			equalNodes(fa.Values, fb.Values, opts)
+	case *ReturnField:
+		fb, ok := b.(*ReturnField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Value, fb.Value, opts)
+	case *BreakField:
+		fb, ok := b.(*BreakField)
+		return ok &&
+			fa.Label == fb.Label
+	case *ContinueField:
+		fb, ok := b.(*ContinueField)
+		return ok &&
+			fa.Label == fb.Label
+	case *CallField:
+		fb, ok := b.(*CallField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			Equal(fa.Ident, fb.Ident, opts) &&
+			Equal(fa.Args, fb.Args, opts)
+	case *IdentField:
+		fb, ok := b.(*IdentField)
+		return ok &&
+			equalTType(fa.TType, fb.TType, opts) &&
+			fa.S == fb.S
+	default:
+		panic(fmt.Sprintf("unexpected field: %T", a))
+	}
+}
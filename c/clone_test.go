@@ -0,0 +1,31 @@
+package c
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	orig := NewNode(VariableDefine, &VariableDefineField{
+		TType: Integer,
+		Ident: NewNode(Ident, &IdentField{S: "x"}),
+		Value: NewNode(Binary, &BinaryField{
+			TType:     Integer,
+			Operation: Add,
+			LHS:       NewNode(Literal, &LiteralField{TType: Integer, I: 1}),
+			RHS:       NewNode(Unary, &UnaryField{TType: Integer, Operation: Neg, Value: NewNode(Ident, &IdentField{S: "y"})}),
+		}),
+	}).WithSpan(Span{Line: 3, Col: 5})
+	cloned := orig.Clone()
+	if diff := cmp.Diff(orig, cloned); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+
+	cloned.GetField().(*VariableDefineField).TType = Bool
+	if Equal(orig, cloned, EqualOptions{}) || !Equal(orig, cloned, EqualOptions{IgnoreTType: true}) {
+		t.Fatal("unexpected type comparison")
+	}
+	if orig.GetField().(*VariableDefineField).TType != Integer {
+		t.Fatal("original was modified")
+	}
+}
@@ -3,22 +3,44 @@ package from_inter
 import (
 	"cape/c"
 	"cape/interlang"
-	"log"
+	"fmt"
 )
 
+// ConvertNodeFromInterLang トップレベルのノードの並びをProgramにまとめてから変換する
 func ConvertNodeFromInterLang(iNodes []*interlang.Node) ([]*c.Node, error) {
-	var nodes []*c.Node
-	for _, in := range iNodes {
-		cn, err := toplevel(in)
-		if err != nil {
-			return nil, err
-		}
-		if cn == nil {
-			continue
+	ip, err := interlang.NewProgram(iNodes)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ConvertProgramFromInterLang(ip)
+	if err != nil {
+		return nil, err
+	}
+	return p.Nodes(), nil
+}
+
+// ConvertProgramFromInterLang 翻訳単位全体を変換する
+func ConvertProgramFromInterLang(ip *interlang.Program) (*c.Program, error) {
+	p := &c.Program{File: ip.Source.Name, Includes: ip.ImportNames()}
+	sections := []struct {
+		iNodes []*interlang.Node
+		nodes  *[]*c.Node
+	}{
+		{ip.Types, &p.Types},
+		{ip.Globals, &p.Globals},
+		{ip.Decls, &p.Decls},
+		{ip.Funcs, &p.Funcs},
+	}
+	for _, section := range sections {
+		for _, iNode := range section.iNodes {
+			node, err := toplevel(iNode)
+			if err != nil {
+				return nil, err
+			}
+			*section.nodes = append(*section.nodes, node)
 		}
-		nodes = append(nodes, cn)
 	}
-	return nodes, nil
+	return p, nil
 }
 
 func convertTypeFromInterLang(tt interlang.TType) (c.TType, error) {
@@ -29,30 +51,96 @@ func convertTypeFromInterLang(tt interlang.TType) (c.TType, error) {
 		return c.String, nil
 	case interlang.Bool:
 		return c.Bool, nil
+	case interlang.Float:
+		return c.Float, nil
 	default:
 		return nil, nil
 	}
 }
 
+// newNode 中間言語のノードの位置を引き継いだノードを作る
+func newNode(iNode *interlang.Node, kind c.NodeKind, field c.Field) *c.Node {
+	return c.NewNode(kind, field).WithSpan(c.Span(iNode.GetSpan()))
+}
+
 func toplevel(iNode *interlang.Node) (*c.Node, error) {
 	switch iNode.GetKind() {
+	case interlang.TypeDefine:
+		return typeDefine(iNode)
+	case interlang.VariableDeclare:
+		return variableDeclare(iNode)
+	case interlang.FunctionDeclare:
+		return functionDeclare(iNode)
+	case interlang.VariableDefine:
+		return variableDefine(iNode)
 	case interlang.FunctionDefine:
 		return functionDefine(iNode)
 	default:
-		return nil, nil
+		return nil, fmt.Errorf("%v: unexpected toplevel node: %v", iNode.GetSpan(), iNode.GetKind())
 	}
 }
 
+func ident(iNode *interlang.Node) *c.Node {
+	iIdentField := iNode.GetField().(*interlang.IdentField)
+	return newNode(iNode, c.Ident, &c.IdentField{S: iIdentField.S})
+}
+
+func typeDefine(iNode *interlang.Node) (*c.Node, error) {
+	iField := iNode.GetField().(*interlang.TypeDefineField)
+	tt, err := convertTypeFromInterLang(iField.GetTType())
+	if err != nil {
+		return nil, err
+	}
+	return newNode(iNode, c.TypeDefine, &c.TypeDefineField{
+		TType: tt,
+		Ident: ident(iField.Ident),
+	}), nil
+}
+
 func variableDeclare(iNode *interlang.Node) (*c.Node, error) {
-	return nil, nil
+	iField := iNode.GetField().(*interlang.VariableDeclareField)
+	tt, err := convertTypeFromInterLang(iField.GetTType())
+	if err != nil {
+		return nil, err
+	}
+	return newNode(iNode, c.VariableDeclare, &c.VariableDeclareField{
+		TType: tt,
+		Ident: ident(iField.Ident),
+	}), nil
 }
 
 func functionDeclare(iNode *interlang.Node) (*c.Node, error) {
-	return nil, nil
+	iField := iNode.GetField().(*interlang.FunctionDeclareField)
+	tt, err := convertTypeFromInterLang(iField.GetTType())
+	if err != nil {
+		return nil, err
+	}
+	params, err := functionDefineParams(iField.Params)
+	if err != nil {
+		return nil, err
+	}
+	return newNode(iNode, c.FunctionDeclare, &c.FunctionDeclareField{
+		TType:  tt,
+		Ident:  ident(iField.Ident),
+		Params: params,
+	}), nil
 }
 
 func variableDefine(iNode *interlang.Node) (*c.Node, error) {
-	return nil, nil
+	iField := iNode.GetField().(*interlang.VariableDefineField)
+	tt, err := convertTypeFromInterLang(iField.GetTType())
+	if err != nil {
+		return nil, err
+	}
+	value, err := expr(iField.Value)
+	if err != nil {
+		return nil, err
+	}
+	return newNode(iNode, c.VariableDefine, &c.VariableDefineField{
+		TType: tt,
+		Ident: ident(iField.Ident),
+		Value: value,
+	}), nil
 }
 
 func functionDefine(iNode *interlang.Node) (*c.Node, error) {
@@ -77,20 +165,40 @@ func functionDefine(iNode *interlang.Node) (*c.Node, error) {
 		return nil, err
 	}
 
-	return c.NewNode(
+	return newNode(
+		iNode,
 		c.FunctionDefine,
 		&c.FunctionDefineField{
 			TType:  RVType,
-			Ident:  c.NewNode(c.Ident, &c.IdentField{S: ident}),
+			Ident:  newNode(iField.Ident, c.Ident, &c.IdentField{S: ident}),
 			Params: params,
 			Block:  stmts,
 		},
 	), nil
 }
 
+// functionDefineParams 仮引数の並び
+// Cには既定値がないので、既定値付きの仮引数はエラーにする
 func functionDefineParams(iNode *interlang.Node) (*c.Node, error) {
-	_ = iNode
-	return nil, nil
+	if iNode == nil {
+		return nil, nil
+	}
+	var params []*c.Node
+	for _, iParam := range iNode.GetField().(*interlang.MultipleField).Values {
+		iParamField := iParam.GetField().(*interlang.ParamField)
+		if iParamField.Default != nil {
+			return nil, fmt.Errorf("%v: default argument is not supported in C", iParam.GetSpan())
+		}
+		tt, err := convertTypeFromInterLang(iParamField.GetTType())
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, newNode(iParam, c.Param, &c.ParamField{TType: tt, Ident: ident(iParamField.Ident)}))
+	}
+	if len(params) == 0 {
+		return nil, nil
+	}
+	return newNode(iNode, c.Multiple, &c.MultipleField{Values: params}), nil
 }
 
 func statement(iNode *interlang.Node) (*c.Node, error) {
@@ -101,28 +209,95 @@ func statement(iNode *interlang.Node) (*c.Node, error) {
 		for _, iStmt := range iStmts {
 			stmt, err := statement(iStmt)
 			if err != nil {
-				continue
+				return nil, err
 			}
 			stmts = append(stmts, stmt)
 		}
-		return c.NewNode(c.Block, &c.BlockField{Stmts: stmts}), nil
+		return newNode(iNode, c.Block, &c.BlockField{Stmts: stmts}), nil
 	case interlang.Return:
 		iReturnField := iNode.GetField().(*interlang.ReturnField)
-		rv, err := expr(iReturnField.Value)
+		// voidの関数のreturn;は値を持たない
+		rv, err := optional(expr, iReturnField.Value)
 		if err != nil {
 			return nil, err
 		}
-		return c.NewNode(c.Return, &c.ReturnField{Value: rv}), nil
+		return newNode(iNode, c.Return, &c.ReturnField{Value: rv}), nil
 	case interlang.IfElse:
-		// TODO
+		iIfElseField := iNode.GetField().(*interlang.IfElseField)
+		cond, err := expr(iIfElseField.Cond)
+		if err != nil {
+			return nil, err
+		}
+		ifBlock, err := statement(iIfElseField.IfBlock)
+		if err != nil {
+			return nil, err
+		}
+		elseBlock, err := optional(statement, iIfElseField.ElseBlock)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(iNode, c.IfElse, &c.IfElseField{
+			Cond:      cond,
+			IfBlock:   ifBlock,
+			ElseBlock: elseBlock,
+		}), nil
 	case interlang.While:
-		// TODO
+		iWhileField := iNode.GetField().(*interlang.WhileField)
+		cond, err := expr(iWhileField.Cond)
+		if err != nil {
+			return nil, err
+		}
+		block, err := statement(iWhileField.Block)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(iNode, c.While, &c.WhileField{
+			Cond:  cond,
+			Block: block,
+			Label: iWhileField.Label,
+		}), nil
 	case interlang.For:
-		// TODO
+		iForField := iNode.GetField().(*interlang.ForField)
+		init, err := optional(statement, iForField.Init)
+		if err != nil {
+			return nil, err
+		}
+		cond, err := optional(expr, iForField.Cond)
+		if err != nil {
+			return nil, err
+		}
+		loop, err := optional(expr, iForField.Loop)
+		if err != nil {
+			return nil, err
+		}
+		block, err := statement(iForField.Block)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(iNode, c.For, &c.ForField{
+			Init:  init,
+			Cond:  cond,
+			Loop:  loop,
+			Block: block,
+			Label: iForField.Label,
+		}), nil
+	case interlang.Break:
+		iBreakField := iNode.GetField().(*interlang.BreakField)
+		return newNode(iNode, c.Break, &c.BreakField{Label: iBreakField.Label}), nil
+	case interlang.Continue:
+		iContinueField := iNode.GetField().(*interlang.ContinueField)
+		return newNode(iNode, c.Continue, &c.ContinueField{Label: iContinueField.Label}), nil
 	default:
 		return expr(iNode)
 	}
-	return nil, nil
+}
+
+// optional 省略可能な子ノードを変換する
+func optional(convert func(*interlang.Node) (*c.Node, error), iNode *interlang.Node) (*c.Node, error) {
+	if iNode == nil {
+		return nil, nil
+	}
+	return convert(iNode)
 }
 
 func expr(iNode *interlang.Node) (*c.Node, error) {
@@ -132,8 +307,9 @@ func expr(iNode *interlang.Node) (*c.Node, error) {
 func assign(iNode *interlang.Node) (*c.Node, error) {
 	switch iNode.GetKind() {
 	case interlang.VariableDeclare:
-		// TODO
-		return nil, nil
+		return variableDeclare(iNode)
+	case interlang.VariableDefine:
+		return variableDefine(iNode)
 	case interlang.Assign:
 		iAssignField := iNode.GetField().(*interlang.AssignField)
 		to, err := expr(iAssignField.To)
@@ -144,258 +320,174 @@ func assign(iNode *interlang.Node) (*c.Node, error) {
 		if err != nil {
 			return nil, err
 		}
-		return c.NewNode(c.Assign, &c.AssignField{
+		return newNode(iNode, c.Assign, &c.AssignField{
 			To:    to,
 			Value: value,
 		}), nil
 	default:
-		return andor(iNode)
+		return binary(iNode)
 	}
 }
 
-func andor(iNode *interlang.Node) (*c.Node, error) {
+// binary 二項演算
+// 演算子ごとに一度だけ左右を変換する
+func binary(iNode *interlang.Node) (*c.Node, error) {
 	if iNode.GetKind() != interlang.Binary {
-		return equality(iNode)
+		return unary(iNode)
 	}
-
 	iBinaryField := iNode.GetField().(*interlang.BinaryField)
-	iType := iBinaryField.GetTType()
-	pType, err := convertTypeFromInterLang(iType)
-	if err != nil {
-		return nil, err
-	}
-	lhs, err := andor(iBinaryField.LHS)
-	if err != nil {
-		return nil, err
-	}
-	rhs, err := andor(iBinaryField.RHS)
-	if err != nil {
-		return nil, err
-	}
-
+	var op c.Operation
 	switch iBinaryField.Operation {
 	case interlang.And:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.And,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.And
 	case interlang.Or:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Or,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
-	default:
-		return equality(iNode)
-	}
-}
-
-func equality(iNode *interlang.Node) (*c.Node, error) {
-	if iNode.GetKind() != interlang.Binary {
-		return relational(iNode)
-	}
-	iBinaryField := iNode.GetField().(*interlang.BinaryField)
-	iType := iBinaryField.GetTType()
-	pType, err := convertTypeFromInterLang(iType)
-	if err != nil {
-		return nil, err
-	}
-	lhs, err := equality(iBinaryField.LHS)
-	if err != nil {
-		return nil, err
-	}
-	rhs, err := equality(iBinaryField.RHS)
-	if err != nil {
-		return nil, err
-	}
-	switch iBinaryField.Operation {
+		op = c.Or
+	case interlang.BitOr:
+		op = c.BitOr
+	case interlang.BitXor:
+		op = c.BitXor
+	case interlang.BitAnd:
+		op = c.BitAnd
 	case interlang.Eq:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Eq,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.Eq
 	case interlang.Ne:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Ne,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
-	default:
-		return relational(iNode)
-	}
-}
-
-func relational(iNode *interlang.Node) (*c.Node, error) {
-	if iNode.GetKind() != interlang.Binary {
-		return add(iNode)
-	}
-	iBinaryField := iNode.GetField().(*interlang.BinaryField)
-	iType := iBinaryField.GetTType()
-	pType, err := convertTypeFromInterLang(iType)
-	if err != nil {
-		return nil, err
-	}
-	lhs, err := relational(iBinaryField.LHS)
-	if err != nil {
-		return nil, err
-	}
-	rhs, err := relational(iBinaryField.RHS)
-	if err != nil {
-		return nil, err
-	}
-	switch iBinaryField.Operation {
+		op = c.Ne
 	case interlang.Lt:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Lt,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.Lt
 	case interlang.Le:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Le,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.Le
 	case interlang.Gt:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Gt,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.Gt
 	case interlang.Ge:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Ge,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
-	default:
-		return add(iNode)
-	}
-}
-
-func add(iNode *interlang.Node) (*c.Node, error) {
-	if iNode.GetKind() != interlang.Binary {
-		return mul(iNode)
-	}
-	iBinaryField := iNode.GetField().(*interlang.BinaryField)
-	iType := iBinaryField.GetTType()
-	pType, err := convertTypeFromInterLang(iType)
-	if err != nil {
-		return nil, err
-	}
-	lhs, err := add(iBinaryField.LHS)
-	if err != nil {
-		return nil, err
-	}
-	rhs, err := add(iBinaryField.RHS)
-	if err != nil {
-		return nil, err
-	}
-	switch iBinaryField.Operation {
+		op = c.Ge
+	case interlang.Shl:
+		op = c.Shl
+	case interlang.Shr:
+		op = c.Shr
 	case interlang.Add:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Add,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.Add
 	case interlang.Sub:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Sub,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
+		op = c.Sub
+	case interlang.Mul:
+		op = c.Mul
+	case interlang.Div:
+		op = c.Div
+	case interlang.Mod:
+		op = c.Mod
 	default:
-		return mul(iNode)
-	}
-}
-
-func mul(iNode *interlang.Node) (*c.Node, error) {
-	if iNode.GetKind() != interlang.Binary {
-		return unary(iNode)
+		return nil, fmt.Errorf("%v: unexpected binary operation: %v", iNode.GetSpan(), iBinaryField.Operation)
 	}
-	iBinaryField := iNode.GetField().(*interlang.BinaryField)
-	iType := iBinaryField.GetTType()
-	pType, err := convertTypeFromInterLang(iType)
+	pType, err := convertTypeFromInterLang(iBinaryField.GetTType())
 	if err != nil {
 		return nil, err
 	}
-	lhs, err := mul(iBinaryField.LHS)
+	lhs, err := binary(iBinaryField.LHS)
 	if err != nil {
 		return nil, err
 	}
-	rhs, err := mul(iBinaryField.RHS)
+	rhs, err := binary(iBinaryField.RHS)
 	if err != nil {
 		return nil, err
 	}
-	switch iBinaryField.Operation {
-	case interlang.Mul:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Mul,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
-	case interlang.Div:
-		return c.NewNode(c.Binary, &c.BinaryField{
-			TType:     pType,
-			Operation: c.Div,
-			LHS:       lhs,
-			RHS:       rhs,
-		}), nil
-	case interlang.Mod:
-		return c.NewNode(c.Binary, &c.BinaryField{
+	return newNode(iNode, c.Binary, &c.BinaryField{
+		TType:     pType,
+		Operation: op,
+		LHS:       lhs,
+		RHS:       rhs,
+	}), nil
+}
+
+func unary(iNode *interlang.Node) (*c.Node, error) {
+	switch iNode.GetKind() {
+	case interlang.Not:
+		iNotField := iNode.GetField().(*interlang.NotField)
+		value, err := unary(iNotField.Value)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(iNode, c.Not, &c.NotField{Value: value}), nil
+	case interlang.Unary:
+		iUnaryField := iNode.GetField().(*interlang.UnaryField)
+		pType, err := convertTypeFromInterLang(iUnaryField.GetTType())
+		if err != nil {
+			return nil, err
+		}
+		value, err := unary(iUnaryField.Value)
+		if err != nil {
+			return nil, err
+		}
+		var op c.Operation
+		switch iUnaryField.Operation {
+		case interlang.Neg:
+			op = c.Neg
+		case interlang.Plus:
+			op = c.Plus
+		case interlang.BitNot:
+			op = c.BitNot
+		case interlang.Addr:
+			op = c.Addr
+		default:
+			return nil, fmt.Errorf("%v: unexpected unary operation: %v", iNode.GetSpan(), iUnaryField.Operation)
+		}
+		return newNode(iNode, c.Unary, &c.UnaryField{
 			TType:     pType,
-			Operation: c.Mod,
-			LHS:       lhs,
-			RHS:       rhs,
+			Operation: op,
+			Value:     value,
 		}), nil
 	default:
-		return unary(iNode)
+		return primary(iNode)
 	}
 }
 
-func unary(iNode *interlang.Node) (*c.Node, error) {
-	return primary(iNode)
-}
-
 func primary(iNode *interlang.Node) (*c.Node, error) {
 	switch iNode.GetKind() {
 	case interlang.Ident:
 		iIdentField := iNode.GetField().(*interlang.IdentField)
-		return c.NewNode(c.Ident, &c.IdentField{TType: c.String, S: iIdentField.S}), nil
+		return newNode(iNode, c.Ident, &c.IdentField{TType: c.String, S: iIdentField.S}), nil
 	case interlang.Literal:
 		return literal(iNode)
 	case interlang.Call:
-		log.Panicf("call unimplemented: %v", iNode)
+		return call(iNode)
 	default:
-		log.Panicf("unexpected primary node: %v", iNode)
+		return nil, fmt.Errorf("%v: unexpected primary node: %v", iNode.GetSpan(), iNode.GetKind())
+	}
+}
+
+func call(iNode *interlang.Node) (*c.Node, error) {
+	iCallField := iNode.GetField().(*interlang.CallField)
+	tt, err := convertTypeFromInterLang(iCallField.GetTType())
+	if err != nil {
+		return nil, err
+	}
+	var args []*c.Node
+	if iCallField.Args != nil {
+		for _, iArg := range iCallField.Args.GetField().(*interlang.MultipleField).Values {
+			arg, err := expr(iArg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
 	}
-	return nil, nil
+	return newNode(iNode, c.Call, &c.CallField{
+		TType: tt,
+		Ident: ident(iCallField.Ident),
+		Args:  newNode(iNode, c.Multiple, &c.MultipleField{Values: args}),
+	}), nil
 }
 
 func literal(iNode *interlang.Node) (*c.Node, error) {
 	iLitField := iNode.GetField().(*interlang.LiteralField)
 	switch iLitField.GetTType() {
 	case interlang.String:
-		return c.NewNode(c.Literal, &c.LiteralField{TType: c.String, S: iLitField.S}), nil
+		return newNode(iNode, c.Literal, &c.LiteralField{TType: c.String, S: iLitField.S}), nil
 	case interlang.Integer:
-		return c.NewNode(c.Literal, &c.LiteralField{TType: c.Integer, I: iLitField.I}), nil
+		return newNode(iNode, c.Literal, &c.LiteralField{TType: c.Integer, I: iLitField.I}), nil
 	case interlang.Bool:
-		log.Panicf("unsupported literal: %v", iNode)
+		return nil, fmt.Errorf("%v: unsupported bool literal", iNode.GetSpan())
+	case interlang.Float:
+		return newNode(iNode, c.Literal, &c.LiteralField{TType: c.Float, F: iLitField.F}), nil
 	default:
-		log.Panicf("unsupported literal: %v", iNode)
+		return nil, fmt.Errorf("%v: unsupported literal: %v", iNode.GetSpan(), iLitField.GetTType())
 	}
-	return nil, nil
 }
@@ -3,6 +3,7 @@ package from_inter
 import (
 	"cape/c"
 	"cape/interlang"
+	"cape/interlang/build"
 	"github.com/google/go-cmp/cmp"
 	"testing"
 )
@@ -36,6 +37,129 @@ func TestConvertNodeFromInterLang(t *testing.T) {
 				}),
 			},
 		},
+		{
+			"shift",
+			[]*interlang.Node{
+				interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
+					TType:  interlang.Integer,
+					Ident:  interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "main"}),
+					Params: nil,
+					Block: interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: []*interlang.Node{
+						interlang.NewNode(interlang.Return, &interlang.ReturnField{Value: interlang.NewNode(interlang.Binary, &interlang.BinaryField{
+							TType:     interlang.Integer,
+							Operation: interlang.Shl,
+							LHS:       interlang.NewNode(interlang.Unary, &interlang.UnaryField{TType: interlang.Integer, Operation: interlang.BitNot, Value: interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 0})}),
+							RHS:       interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 4}),
+						})}),
+					}}),
+				}),
+			},
+			[]*c.Node{
+				c.NewNode(c.FunctionDefine, &c.FunctionDefineField{
+					TType:  c.Integer,
+					Ident:  c.NewNode(c.Ident, &c.IdentField{S: "main"}),
+					Params: nil,
+					Block: c.NewNode(c.Block, &c.BlockField{Stmts: []*c.Node{
+						c.NewNode(c.Return, &c.ReturnField{Value: c.NewNode(c.Binary, &c.BinaryField{
+							TType:     c.Integer,
+							Operation: c.Shl,
+							LHS:       c.NewNode(c.Unary, &c.UnaryField{TType: c.Integer, Operation: c.BitNot, Value: c.NewNode(c.Literal, &c.LiteralField{TType: c.Integer, I: 0})}),
+							RHS:       c.NewNode(c.Literal, &c.LiteralField{TType: c.Integer, I: 4}),
+						})}),
+					}}),
+				}),
+			},
+		},
+		{
+			"while",
+			[]*interlang.Node{
+				interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
+					TType:  interlang.Integer,
+					Ident:  interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "main"}),
+					Params: nil,
+					Block: interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: []*interlang.Node{
+						interlang.NewNode(interlang.While, &interlang.WhileField{
+							Cond: interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 1}),
+							Block: interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: []*interlang.Node{
+								interlang.NewNode(interlang.Continue, &interlang.ContinueField{Label: "loop"}),
+								interlang.NewNode(interlang.Break, &interlang.BreakField{}),
+							}}),
+							Label: "loop",
+						}),
+					}}),
+				}),
+			},
+			[]*c.Node{
+				c.NewNode(c.FunctionDefine, &c.FunctionDefineField{
+					TType:  c.Integer,
+					Ident:  c.NewNode(c.Ident, &c.IdentField{S: "main"}),
+					Params: nil,
+					Block: c.NewNode(c.Block, &c.BlockField{Stmts: []*c.Node{
+						c.NewNode(c.While, &c.WhileField{
+							Cond: c.NewNode(c.Literal, &c.LiteralField{TType: c.Integer, I: 1}),
+							Block: c.NewNode(c.Block, &c.BlockField{Stmts: []*c.Node{
+								c.NewNode(c.Continue, &c.ContinueField{Label: "loop"}),
+								c.NewNode(c.Break, &c.BreakField{}),
+							}}),
+							Label: "loop",
+						}),
+					}}),
+				}),
+			},
+		},
+		{
+			"globals",
+			[]*interlang.Node{
+				build.FuncDecl("main", build.Int, build.Params()),
+				build.Define("limit", build.Int, build.IntLit(10)),
+				build.TypeDef("score", build.Int),
+			},
+			[]*c.Node{
+				c.NewNode(c.TypeDefine, &c.TypeDefineField{
+					TType: c.Integer,
+					Ident: c.NewNode(c.Ident, &c.IdentField{S: "score"}),
+				}),
+				c.NewNode(c.VariableDefine, &c.VariableDefineField{
+					TType: c.Integer,
+					Ident: c.NewNode(c.Ident, &c.IdentField{S: "limit"}),
+					Value: c.NewNode(c.Literal, &c.LiteralField{TType: c.Integer, I: 10}),
+				}),
+				c.NewNode(c.FunctionDeclare, &c.FunctionDeclareField{
+					TType: c.Integer,
+					Ident: c.NewNode(c.Ident, &c.IdentField{S: "main"}),
+				}),
+			},
+		},
+		{
+			"void return",
+			[]*interlang.Node{
+				build.Func("f", interlang.Null, build.Params(), build.Block(build.Return(nil))),
+			},
+			[]*c.Node{
+				c.NewNode(c.FunctionDefine, &c.FunctionDefineField{
+					Ident: c.NewNode(c.Ident, &c.IdentField{S: "f"}),
+					Block: c.NewNode(c.Block, &c.BlockField{Stmts: []*c.Node{
+						c.NewNode(c.Return, &c.ReturnField{}),
+					}}),
+				}),
+			},
+		},
+		{
+			"params",
+			[]*interlang.Node{
+				build.FuncDecl("add", build.Int, build.Params(build.Param("a", build.Int), build.Param("b", build.Int))),
+			},
+			[]*c.Node{
+				c.NewNode(c.FunctionDeclare, &c.FunctionDeclareField{
+					TType: c.Integer,
+					Ident: c.NewNode(c.Ident, &c.IdentField{S: "add"}),
+					Params: c.NewNode(c.Multiple, &c.MultipleField{Values: []*c.Node{
+						c.NewNode(c.Param, &c.ParamField{TType: c.Integer, Ident: c.NewNode(c.Ident, &c.IdentField{S: "a"})}),
+						c.NewNode(c.Param, &c.ParamField{TType: c.Integer, Ident: c.NewNode(c.Ident, &c.IdentField{S: "b"})}),
+					}}),
+				}),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
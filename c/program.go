@@ -0,0 +1,27 @@
+package c
+
+// Program 翻訳単位全体
+type Program struct {
+	// 翻訳元のファイル名
+	File string
+	// #includeするヘッダ名
+	Includes []string
+	// TypeDefine
+	Types []*Node
+	// VariableDeclare、VariableDefine
+	Globals []*Node
+	// FunctionDeclare
+	Decls []*Node
+	// FunctionDefine
+	Funcs []*Node
+}
+
+// Nodes 型定義、大域変数、関数宣言、関数定義の順にトップレベルのノードを並べる
+func (p *Program) Nodes() []*Node {
+	var nodes []*Node
+	nodes = append(nodes, p.Types...)
+	nodes = append(nodes, p.Globals...)
+	nodes = append(nodes, p.Decls...)
+	nodes = append(nodes, p.Funcs...)
+	return nodes
+}
@@ -12,16 +12,31 @@ const (
 	Integer
 	String
 	Bool
+	Float
 )
 
-func (tt TPrimitive) IsEqual(tt2 TType) bool { // TODO
-	_ = tt2
-	return false
+func (tt TPrimitive) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TPrimitive)
+	return ok && tt == other
 }
 
 type TTuple []TType
 
-func (tt TTuple) IsEqual(tt2 TType) bool { // TODO
-	_ = tt2
-	return false
+func (tt TTuple) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TTuple)
+	if !ok || len(tt) != len(other) {
+		return false
+	}
+	for i := range tt {
+		if tt[i] == nil || other[i] == nil {
+			if tt[i] != other[i] {
+				return false
+			}
+			continue
+		}
+		if !tt[i].IsEqual(other[i]) {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,438 @@
+// nodegen spec.goのノードの定義から、各言語のパッケージのノードの種類、フィールド、演算子、
+// 木を辿る関数、複製と比較の関数を生成する
+//
+//	//go:generate go run cape/cmd/nodegen -dialect interlang
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const header = "// Code generated by cmd/nodegen. DO NOT EDIT.\n\n"
+
+func main() {
+	name := flag.String("dialect", "", "interlang, c or python")
+	dir := flag.String("dir", ".", "output directory")
+	flag.Parse()
+
+	d, ok := dialects[*name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "nodegen: unknown dialect: %q\n", *name)
+		os.Exit(2)
+	}
+	files, err := generate(d, *name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "nodegen: %v\n", err)
+		os.Exit(1)
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(*dir, name), files[name], 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "nodegen: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// generate パッケージpkgに置くファイルの名前と中身
+func generate(d dialect, pkg string) (map[string][]byte, error) {
+	var ks []kind
+	for _, k := range kinds {
+		if k.Dialects&d == 0 {
+			continue
+		}
+		var fs []field
+		for _, f := range k.Fields {
+			if f.Dialects == 0 || f.Dialects&d != 0 {
+				fs = append(fs, f)
+			}
+		}
+		k.Fields = fs
+		ks = append(ks, k)
+	}
+
+	gens := map[string]func(*bytes.Buffer, []kind){
+		"node_gen.go":  genNode,
+		"field_gen.go": genField,
+		"op_gen.go":    genOp,
+		"walk_gen.go":  genWalk,
+		"clone_gen.go": genClone,
+	}
+	files := map[string][]byte{}
+	for name, gen := range gens {
+		var b bytes.Buffer
+		b.WriteString(header)
+		fmt.Fprintf(&b, "package %s\n\n", pkg)
+		gen(&b, ks)
+		src, err := format.Source(b.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		files[name] = src
+	}
+	return files, nil
+}
+
+func genNode(b *bytes.Buffer, ks []kind) {
+	b.WriteString("import \"fmt\"\n\n")
+	b.WriteString("type NodeKind int\n\nconst (\n\t_ NodeKind = iota\n")
+	for _, k := range ks {
+		if k.Group {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "\t%s\n", k.Name)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("var nodeKinds = [...]string{\n")
+	for _, k := range ks {
+		if k.Group {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "\t%s: %q,\n", k.Name, k.Name)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString(`func (k NodeKind) String() string {
+	if 0 < k && int(k) < len(nodeKinds) {
+		return nodeKinds[k]
+	}
+	return fmt.Sprintf("NodeKind(%d)", int(k))
+}
+
+func nodeKindFromString(s string) (NodeKind, bool) {
+	for k, name := range nodeKinds {
+		if name != "" && name == s {
+			return NodeKind(k), true
+		}
+	}
+	return 0, false
+}
+
+// newField kindに対応する空のフィールドを作る
+func newField(kind NodeKind) Field {
+	switch kind {
+`)
+	for _, k := range ks {
+		fmt.Fprintf(b, "\tcase %s:\n\t\treturn &%sField{}\n", k.Name, k.Name)
+	}
+	b.WriteString("\tdefault:\n\t\treturn nil\n\t}\n}\n")
+}
+
+func hasTType(k kind) bool {
+	for _, f := range k.Fields {
+		if f.Type == tType {
+			return true
+		}
+	}
+	return false
+}
+
+func genField(b *bytes.Buffer, ks []kind) {
+	for _, k := range ks {
+		if k.Doc != "" {
+			fmt.Fprintf(b, "// %sField %s\n", k.Name, k.Doc)
+		}
+		fmt.Fprintf(b, "type %sField struct {\n", k.Name)
+		for _, f := range k.Fields {
+			if f.Name == f.Type {
+				fmt.Fprintf(b, "\t%s\n", f.Name)
+			} else {
+				fmt.Fprintf(b, "\t%s %s\n", f.Name, f.Type)
+			}
+		}
+		b.WriteString("}\n\n")
+		fmt.Fprintf(b, "func (f *%sField) GetKind() FieldKind {\n\treturn %s\n}\n", k.Name, k.Name)
+		switch {
+		case k.TType != "":
+			fmt.Fprintf(b, "func (f *%sField) GetTType() TType {\n\treturn %s\n}\n", k.Name, k.TType)
+		case hasTType(k):
+			fmt.Fprintf(b, "func (f *%sField) GetTType() TType {\n\treturn f.TType\n}\n", k.Name)
+		}
+		b.WriteString("\n")
+	}
+}
+
+func genOp(b *bytes.Buffer, _ []kind) {
+	b.WriteString("import \"fmt\"\n\n")
+	b.WriteString("type Operation int\n\nconst (\n\t_ Operation = iota\n")
+	for _, o := range ops {
+		if o.Group {
+			b.WriteString("\n")
+		}
+		if o.Doc != "" {
+			fmt.Fprintf(b, "\t// %s\n", o.Doc)
+		}
+		fmt.Fprintf(b, "\t%s\n", o.Name)
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("var operations = [...]string{\n")
+	for _, o := range ops {
+		if o.Group {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "\t%s: %q,\n", o.Name, o.Name)
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// IsUnary 単項演算子か\nfunc (op Operation) IsUnary() bool {\n\tswitch op {\n\tcase ")
+	var first = true
+	for _, o := range ops {
+		if !o.Unary {
+			continue
+		}
+		if !first {
+			b.WriteString(", ")
+		}
+		first = false
+		b.WriteString(o.Name)
+	}
+	b.WriteString(`:
+		return true
+	default:
+		return false
+	}
+}
+
+func (op Operation) String() string {
+	if 0 < op && int(op) < len(operations) {
+		return operations[op]
+	}
+	return fmt.Sprintf("Operation(%d)", int(op))
+}
+
+func operationFromString(s string) (Operation, bool) {
+	for op, name := range operations {
+		if name != "" && name == s {
+			return Operation(op), true
+		}
+	}
+	return 0, false
+}
+`)
+}
+
+func genWalk(b *bytes.Buffer, ks []kind) {
+	b.WriteString(`// Children 子ノードをフィールドの順に返す
+// 省略されている子ノードは含めない
+func (n *Node) Children() []*Node {
+	var children []*Node
+	add := func(nodes ...*Node) {
+		for _, node := range nodes {
+			if node != nil {
+				children = append(children, node)
+			}
+		}
+	}
+	switch f := n.Field.(type) {
+`)
+	for _, k := range ks {
+		var args []string
+		for _, f := range k.Fields {
+			switch f.Type {
+			case node:
+				args = append(args, "f."+f.Name)
+			case nodes:
+				args = append(args, "f."+f.Name+"...")
+			}
+		}
+		if len(args) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\tcase *%sField:\n", k.Name)
+		// 可変長引数は一つしか渡せないので一つずつ加える
+		for _, arg := range args {
+			fmt.Fprintf(b, "\t\tadd(%s)\n", arg)
+		}
+	}
+	b.WriteString(`	}
+	return children
+}
+
+// Walk nodeから深さ優先で辿り、各ノードでfを呼ぶ
+// fがfalseを返したノードの子ノードは辿らない
+func Walk(node *Node, f func(*Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	for _, child := range node.Children() {
+		Walk(child, f)
+	}
+}
+`)
+}
+
+func genClone(b *bytes.Buffer, ks []kind) {
+	b.WriteString(`import "fmt"
+
+// EqualOptions Equalで比べずに無視する部分
+type EqualOptions struct {
+	// 位置を比べない
+	IgnoreSpan bool
+	// 型を比べない
+	IgnoreTType bool
+}
+
+// Clone 部分木を深く複製する
+func (n *Node) Clone() *Node {
+	if n == nil {
+		return nil
+	}
+	return &Node{NodeKind: n.NodeKind, Field: cloneField(n.Field), Span: n.Span}
+}
+
+func cloneNodes(nodes []*Node) []*Node {
+	if nodes == nil {
+		return nil
+	}
+	cloned := make([]*Node, len(nodes))
+	for i, node := range nodes {
+		cloned[i] = node.Clone()
+	}
+	return cloned
+}
+
+func cloneTType(tt TType) TType {
+	switch tt := tt.(type) {
+	case TTuple:
+		cloned := make(TTuple, len(tt))
+		for i, t := range tt {
+			cloned[i] = cloneTType(t)
+		}
+		return cloned
+	default:
+		return tt
+	}
+}
+
+func cloneField(field Field) Field {
+	switch f := field.(type) {
+	case nil:
+		return nil
+`)
+	for _, k := range ks {
+		fmt.Fprintf(b, "\tcase *%sField:\n\t\treturn &%sField{\n", k.Name, k.Name)
+		for _, f := range k.Fields {
+			var v string
+			switch f.Type {
+			case tType:
+				v = "cloneTType(f.TType)"
+			case node:
+				v = "f." + f.Name + ".Clone()"
+			case nodes:
+				v = "cloneNodes(f." + f.Name + ")"
+			case strs:
+				v = "append([]string(nil), f." + f.Name + "...)"
+			default:
+				v = "f." + f.Name
+			}
+			fmt.Fprintf(b, "\t\t\t%s: %s,\n", f.Name, v)
+		}
+		b.WriteString("\t\t}\n")
+	}
+	b.WriteString(`	default:
+		panic(fmt.Sprintf("unexpected field: %T", field))
+	}
+}
+
+// Equal 二つの部分木が同じ形か
+func Equal(a, b *Node, opts EqualOptions) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.NodeKind != b.NodeKind {
+		return false
+	}
+	if !opts.IgnoreSpan && a.Span != b.Span {
+		return false
+	}
+	return equalField(a.Field, b.Field, opts)
+}
+
+func equalNodes(a, b []*Node, opts EqualOptions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i], opts) {
+			return false
+		}
+	}
+	return true
+}
+`)
+	usesStrs := false
+	for _, k := range ks {
+		for _, f := range k.Fields {
+			if f.Type == strs {
+				usesStrs = true
+			}
+		}
+	}
+	if usesStrs {
+		b.WriteString(`
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+`)
+	}
+	b.WriteString(`
+func equalTType(a, b TType, opts EqualOptions) bool {
+	if opts.IgnoreTType {
+		return true
+	}
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.IsEqual(b)
+}
+
+func equalField(a, b Field, opts EqualOptions) bool {
+	switch fa := a.(type) {
+	case nil:
+		return b == nil
+`)
+	for _, k := range ks {
+		fmt.Fprintf(b, "\tcase *%sField:\n\t\tfb, ok := b.(*%sField)\n\t\treturn ok", k.Name, k.Name)
+		for _, f := range k.Fields {
+			b.WriteString(" &&\n\t\t\t")
+			switch f.Type {
+			case tType:
+				b.WriteString("equalTType(fa.TType, fb.TType, opts)")
+			case node:
+				fmt.Fprintf(b, "Equal(fa.%s, fb.%s, opts)", f.Name, f.Name)
+			case nodes:
+				fmt.Fprintf(b, "equalNodes(fa.%s, fb.%s, opts)", f.Name, f.Name)
+			case strs:
+				fmt.Fprintf(b, "equalStrings(fa.%s, fb.%s)", f.Name, f.Name)
+			default:
+				fmt.Fprintf(b, "fa.%s == fb.%s", f.Name, f.Name)
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(`	default:
+		panic(fmt.Sprintf("unexpected field: %T", a))
+	}
+}
+`)
+}
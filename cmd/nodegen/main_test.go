@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerated 生成済みのファイルがspec.goと食い違っていないか
+func TestGenerated(t *testing.T) {
+	for name, d := range dialects {
+		files, err := generate(d, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for file, src := range files {
+			path := filepath.Join("..", "..", name, file)
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, src) {
+				t.Errorf("%s is out of date; run go generate ./...", path)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+// dialect ノードを持つパッケージ
+type dialect int
+
+const (
+	interlang dialect = 1 << iota
+	c
+	python
+
+	all = interlang | c | python
+)
+
+var dialects = map[string]dialect{
+	"interlang": interlang,
+	"c":         c,
+	"python":    python,
+}
+
+// kind ノードの種類とそのフィールド
+type kind struct {
+	Name string
+	// フィールドの構造体に付けるコメント
+	Doc      string
+	Dialects dialect
+	Fields   []field
+	// GetTTypeがフィールドの代わりに返す型
+	TType string
+	// 直前に空行を入れる
+	Group bool
+}
+
+type field struct {
+	// 埋め込みの場合は型名と同じ
+	Name string
+	Type string
+	// 0ならkindと同じ
+	Dialects dialect
+}
+
+// フィールドの型
+const (
+	tType     = "TType"
+	operation = "Operation"
+	node      = "*Node"
+	nodes     = "[]*Node"
+	str       = "string"
+	strs      = "[]string"
+	integer   = "int"
+	float     = "float64"
+)
+
+var (
+	fTType     = field{Name: tType, Type: tType}
+	fOperation = field{Name: operation, Type: operation}
+)
+
+// kinds 全てのノードの種類
+var kinds = []kind{
+	{Name: "VariableDeclare", Dialects: interlang | c, Fields: []field{fTType, {Name: "Ident", Type: node}}},
+	{Name: "FunctionDeclare", Dialects: interlang | c, Fields: []field{fTType, {Name: "Ident", Type: node}, {Name: "Params", Type: node}}},
+	{Name: "VariableDefine", Dialects: interlang | c, Fields: []field{fTType, {Name: "Ident", Type: node}, {Name: "Value", Type: node}}},
+	{Name: "FunctionDefine", Dialects: all, Fields: []field{fTType, {Name: "Ident", Type: node}, {Name: "Params", Type: node}, {Name: "Block", Type: node}}},
+	{Name: "TypeDefine", Doc: "typedefでTTypeにIdentという別名を付ける", Dialects: interlang | c, Fields: []field{fTType, {Name: "Ident", Type: node}}},
+	{Name: "Param", Doc: "関数の仮引数 Defaultは省略できる", Dialects: all, Fields: []field{fTType, {Name: "Ident", Type: node}, {Name: "Default", Type: node}}},
+	{Name: "Import", Doc: "import文", Dialects: python, Fields: []field{{Name: "Module", Type: str}}},
+
+	{Name: "Block", Group: true, Dialects: all, Fields: []field{{Name: "Stmts", Type: nodes}}},
+	{Name: "Global", Doc: "関数の中で代入する大域変数の宣言", Dialects: python, Fields: []field{{Name: "Names", Type: strs}}},
+	{Name: "IfElse", Dialects: all, Fields: []field{{Name: "Cond", Type: node}, {Name: "IfBlock", Type: node}, {Name: "ElseBlock", Type: node}}},
+	{Name: "While", Dialects: all, Fields: []field{{Name: "Cond", Type: node}, {Name: "Block", Type: node}, {Name: "Label", Type: str}}},
+	{Name: "For", Dialects: all, Fields: []field{{Name: "Init", Type: node}, {Name: "Cond", Type: node}, {Name: "Loop", Type: node}, {Name: "Block", Type: node}, {Name: "Label", Type: str}}},
+	{Name: "ForIn", Doc: "for Target in Iter: の形のループ", Dialects: python, Fields: []field{{Name: "Target", Type: node}, {Name: "Iter", Type: node}, {Name: "Block", Type: node}, {Name: "Label", Type: str}}},
+	// pythonではTTypeがあれば変数の宣言を兼ね、型ヒントを付けられる
+	// Valueがなければ宣言だけで、型ヒントを付ける場合にだけ x: int を生成する
+	{Name: "Assign", Dialects: all, Fields: []field{{Name: "To", Type: node}, {Name: "Value", Type: node}, {Name: tType, Type: tType, Dialects: python}}},
+	{Name: "Binary", Dialects: all, Fields: []field{fTType, fOperation, {Name: "LHS", Type: node}, {Name: "RHS", Type: node}}},
+	{Name: "Literal", Dialects: all, Fields: []field{fTType, {Name: "I", Type: integer}, {Name: "F", Type: float}, {Name: "S", Type: str}}},
+	{Name: "Not", Dialects: all, TType: "Bool", Fields: []field{{Name: "Value", Type: node}}},
+	{Name: "Unary", Dialects: all, Fields: []field{fTType, fOperation, {Name: "Value", Type: node}}},
+	{Name: "Multiple", Dialects: all, Fields: []field{fTType, {Name: "Values", Type: nodes}}},
+	{Name: "Return", Dialects: all, Fields: []field{fTType, {Name: "Value", Type: node}}},
+	{Name: "Break", Doc: "Labelが空なら最も内側のループを抜ける", Dialects: all, Fields: []field{{Name: "Label", Type: str}}},
+	{Name: "Continue", Doc: "Labelが空なら最も内側のループの次の周回に進む", Dialects: all, Fields: []field{{Name: "Label", Type: str}}},
+	{Name: "Call", Dialects: all, Fields: []field{fTType, {Name: "Ident", Type: node}, {Name: "Args", Type: node}}},
+
+	{Name: "Ident", Group: true, Dialects: all, Fields: []field{fTType, {Name: "S", Type: str}}},
+}
+
+// op 演算子
+type op struct {
+	Name  string
+	Unary bool
+	// 直前に空行を入れる
+	Group bool
+	// 直前に付けるコメント
+	Doc string
+}
+
+// ops 全ての演算子
+var ops = []op{
+	{Name: "Add"},
+	{Name: "Sub"},
+	{Name: "Mul"},
+	{Name: "Div"},
+	{Name: "Mod"},
+
+	{Name: "And", Group: true},
+	{Name: "Or"},
+
+	{Name: "Eq", Group: true},
+	{Name: "Ne"},
+
+	{Name: "Lt", Group: true},
+	{Name: "Le"},
+	{Name: "Gt"},
+	{Name: "Ge"},
+
+	{Name: "BitAnd", Group: true},
+	{Name: "BitOr"},
+	{Name: "BitXor"},
+	{Name: "Shl"},
+	{Name: "Shr"},
+
+	{Name: "Neg", Unary: true, Group: true, Doc: "単項演算"},
+	{Name: "Plus", Unary: true},
+	{Name: "BitNot", Unary: true},
+	{Name: "Addr", Unary: true},
+}
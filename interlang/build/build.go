@@ -0,0 +1,302 @@
+// Package build 中間言語の木を短く組み立てるための関数群
+//
+//	build.Func("main", build.Int, build.Params(), build.Block(
+//		build.Call("printf", build.Str("%d\n"), build.Bin(build.Add, build.Id("x"), build.IntLit(1))),
+//		build.Return(build.IntLit(0)),
+//	))
+package build
+
+import "cape/interlang"
+
+// 型
+const (
+	Null   = interlang.Null
+	Int    = interlang.Integer
+	String = interlang.String
+	Bool   = interlang.Bool
+	Float  = interlang.Float
+)
+
+// 演算子
+const (
+	Add = interlang.Add
+	Sub = interlang.Sub
+	Mul = interlang.Mul
+	Div = interlang.Div
+	Mod = interlang.Mod
+
+	And = interlang.And
+	Or  = interlang.Or
+
+	Eq = interlang.Eq
+	Ne = interlang.Ne
+
+	Lt = interlang.Lt
+	Le = interlang.Le
+	Gt = interlang.Gt
+	Ge = interlang.Ge
+
+	BitAnd = interlang.BitAnd
+	BitOr  = interlang.BitOr
+	BitXor = interlang.BitXor
+	Shl    = interlang.Shl
+	Shr    = interlang.Shr
+
+	Neg    = interlang.Neg
+	Plus   = interlang.Plus
+	BitNot = interlang.BitNot
+	Addr   = interlang.Addr
+)
+
+// Func 関数定義
+func Func(name string, tt interlang.TType, params *interlang.Node, block *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
+		TType:  tt,
+		Ident:  Id(name),
+		Params: params,
+		Block:  block,
+	})
+}
+
+// FuncDecl 関数宣言(プロトタイプ)
+func FuncDecl(name string, tt interlang.TType, params *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.FunctionDeclare, &interlang.FunctionDeclareField{
+		TType:  tt,
+		Ident:  Id(name),
+		Params: params,
+	})
+}
+
+// Params 仮引数の並び
+// 引数がなければnilを返す
+func Params(params ...*interlang.Node) *interlang.Node {
+	if len(params) == 0 {
+		return nil
+	}
+	return Multiple(params...)
+}
+
+// Param 仮引数
+func Param(name string, tt interlang.TType) *interlang.Node {
+	return ParamDefault(name, tt, nil)
+}
+
+// ParamDefault 既定値付きの仮引数
+func ParamDefault(name string, tt interlang.TType, value *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Param, &interlang.ParamField{
+		TType:   tt,
+		Ident:   Var(name, tt),
+		Default: value,
+	})
+}
+
+// TypeDef 型に別名を付ける
+func TypeDef(name string, tt interlang.TType) *interlang.Node {
+	return interlang.NewNode(interlang.TypeDefine, &interlang.TypeDefineField{
+		TType: tt,
+		Ident: Var(name, tt),
+	})
+}
+
+// Declare 初期値なしの変数宣言
+func Declare(name string, tt interlang.TType) *interlang.Node {
+	return interlang.NewNode(interlang.VariableDeclare, &interlang.VariableDeclareField{
+		TType: tt,
+		Ident: Var(name, tt),
+	})
+}
+
+// Define 初期値ありの変数定義
+func Define(name string, tt interlang.TType, value *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.VariableDefine, &interlang.VariableDefineField{
+		TType: tt,
+		Ident: Var(name, tt),
+		Value: value,
+	})
+}
+
+// Block 文の並び
+func Block(stmts ...*interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: stmts})
+}
+
+// If else節のないif文
+func If(cond *interlang.Node, ifBlock *interlang.Node) *interlang.Node {
+	return IfElse(cond, ifBlock, nil)
+}
+
+// IfElse if文
+// elseBlockにIfElseを渡すとelse ifになる
+func IfElse(cond *interlang.Node, ifBlock *interlang.Node, elseBlock *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.IfElse, &interlang.IfElseField{
+		Cond:      cond,
+		IfBlock:   ifBlock,
+		ElseBlock: elseBlock,
+	})
+}
+
+// While while文
+func While(cond *interlang.Node, block *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.While, &interlang.WhileField{
+		Cond:  cond,
+		Block: block,
+	})
+}
+
+// For for文
+// 省略する部分にはnilを渡す
+func For(init, cond, loop *interlang.Node, block *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.For, &interlang.ForField{
+		Init:  init,
+		Cond:  cond,
+		Loop:  loop,
+		Block: block,
+	})
+}
+
+// Labeled ループにラベルを付ける
+func Labeled(label string, loop *interlang.Node) *interlang.Node {
+	switch field := loop.GetField().(type) {
+	case *interlang.WhileField:
+		field.Label = label
+	case *interlang.ForField:
+		field.Label = label
+	}
+	return loop
+}
+
+// Break 最も内側のループを抜ける
+func Break() *interlang.Node {
+	return BreakTo("")
+}
+
+// BreakTo ラベルの付いたループを抜ける
+func BreakTo(label string) *interlang.Node {
+	return interlang.NewNode(interlang.Break, &interlang.BreakField{Label: label})
+}
+
+// Continue 最も内側のループの次の周回に進む
+func Continue() *interlang.Node {
+	return ContinueTo("")
+}
+
+// ContinueTo ラベルの付いたループの次の周回に進む
+func ContinueTo(label string) *interlang.Node {
+	return interlang.NewNode(interlang.Continue, &interlang.ContinueField{Label: label})
+}
+
+// Return return文
+// 値を返さない場合はnilを渡す
+func Return(value *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Return, &interlang.ReturnField{
+		TType: typeOf(value),
+		Value: value,
+	})
+}
+
+// Assign 代入
+func Assign(to *interlang.Node, value *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Assign, &interlang.AssignField{
+		To:    to,
+		Value: value,
+	})
+}
+
+// Bin 二項演算
+// 比較と論理演算の型はBool、シフトは左辺の型になる
+// それ以外はCの通常の算術変換に倣い、どちらかがFloatならFloat、そうでなければオペランドの型になる
+func Bin(op interlang.Operation, lhs, rhs *interlang.Node) *interlang.Node {
+	var tt interlang.TType
+	switch op {
+	case And, Or, Eq, Ne, Lt, Le, Gt, Ge:
+		tt = Bool
+	case Shl, Shr:
+		tt = typeOf(lhs)
+	default:
+		tt = typeOf(lhs)
+		if tt == nil || typeOf(rhs) == Float {
+			tt = typeOf(rhs)
+		}
+	}
+	return interlang.NewNode(interlang.Binary, &interlang.BinaryField{
+		TType:     tt,
+		Operation: op,
+		LHS:       lhs,
+		RHS:       rhs,
+	})
+}
+
+// Un 単項演算
+func Un(op interlang.Operation, value *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Unary, &interlang.UnaryField{
+		TType:     typeOf(value),
+		Operation: op,
+		Value:     value,
+	})
+}
+
+// Not 論理否定
+func Not(value *interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Not, &interlang.NotField{Value: value})
+}
+
+// Call 関数呼び出し
+func Call(name string, args ...*interlang.Node) *interlang.Node {
+	return interlang.NewNode(interlang.Call, &interlang.CallField{
+		Ident: Id(name),
+		Args:  Multiple(args...),
+	})
+}
+
+// Multiple 値の並び
+func Multiple(values ...*interlang.Node) *interlang.Node {
+	field := &interlang.MultipleField{Values: values}
+	if len(values) != 0 {
+		var tt interlang.TTuple
+		for _, value := range values {
+			tt = append(tt, typeOf(value))
+		}
+		field.TType = tt
+	}
+	return interlang.NewNode(interlang.Multiple, field)
+}
+
+// IntLit 整数リテラル
+func IntLit(i int) *interlang.Node {
+	return interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: Int, I: i})
+}
+
+// FloatLit 浮動小数点数のリテラル
+func FloatLit(f float64) *interlang.Node {
+	return interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: Float, F: f})
+}
+
+// Str 文字列リテラル
+func Str(s string) *interlang.Node {
+	return interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: String, S: s})
+}
+
+// Id 型の分からない識別子
+func Id(name string) *interlang.Node {
+	return interlang.NewNode(interlang.Ident, &interlang.IdentField{S: name})
+}
+
+// Var 型付きの識別子
+func Var(name string, tt interlang.TType) *interlang.Node {
+	return interlang.NewNode(interlang.Ident, &interlang.IdentField{TType: tt, S: name})
+}
+
+// typeOf 式の型を分かる範囲で返す
+func typeOf(node *interlang.Node) interlang.TType {
+	if node == nil {
+		return nil
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.IdentField:
+		return field.TType
+	case interface{ GetTType() interlang.TType }:
+		return field.GetTType()
+	default:
+		return nil
+	}
+}
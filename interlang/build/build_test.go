@@ -0,0 +1,99 @@
+package build
+
+import (
+	"cape/interlang"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     *interlang.Node
+		expect *interlang.Node
+	}{
+		{
+			"func",
+			Func("main", Int, Params(), Block(Return(IntLit(32)))),
+			interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
+				TType:  interlang.Integer,
+				Ident:  interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "main"}),
+				Params: nil,
+				Block: interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: []*interlang.Node{
+					interlang.NewNode(interlang.Return, &interlang.ReturnField{
+						TType: interlang.Integer,
+						Value: interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 32}),
+					}),
+				}}),
+			}),
+		},
+		{
+			"binary",
+			Bin(Lt, Bin(Add, Var("x", Int), IntLit(1)), IntLit(10)),
+			interlang.NewNode(interlang.Binary, &interlang.BinaryField{
+				TType:     interlang.Bool,
+				Operation: interlang.Lt,
+				LHS: interlang.NewNode(interlang.Binary, &interlang.BinaryField{
+					TType:     interlang.Integer,
+					Operation: interlang.Add,
+					LHS:       interlang.NewNode(interlang.Ident, &interlang.IdentField{TType: interlang.Integer, S: "x"}),
+					RHS:       interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 1}),
+				}),
+				RHS: interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 10}),
+			}),
+		},
+		{
+			"usual arithmetic conversions",
+			Bin(Div, Var("a", Int), FloatLit(2)),
+			interlang.NewNode(interlang.Binary, &interlang.BinaryField{
+				TType:     interlang.Float,
+				Operation: interlang.Div,
+				LHS:       interlang.NewNode(interlang.Ident, &interlang.IdentField{TType: interlang.Integer, S: "a"}),
+				RHS:       interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Float, F: 2}),
+			}),
+		},
+		{
+			"call",
+			Call("printf", Str("%d\n"), Id("x")),
+			interlang.NewNode(interlang.Call, &interlang.CallField{
+				Ident: interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "printf"}),
+				Args: interlang.NewNode(interlang.Multiple, &interlang.MultipleField{
+					TType: interlang.TTuple{interlang.String, nil},
+					Values: []*interlang.Node{
+						interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.String, S: "%d\n"}),
+						interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "x"}),
+					},
+				}),
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expect, tt.in); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestBuildValidates(t *testing.T) {
+	prog := []*interlang.Node{
+		Define("total", Int, IntLit(0)),
+		Func("main", Int, Params(), Block(
+			Declare("i", Int),
+			For(Assign(Var("i", Int), IntLit(0)), Bin(Lt, Var("i", Int), IntLit(10)), Assign(Var("i", Int), Bin(Add, Var("i", Int), IntLit(1))), Block(
+				If(Not(Bin(Eq, Bin(Mod, Var("i", Int), IntLit(2)), IntLit(0))), Block(
+					Assign(Var("total", Int), Bin(Add, Var("total", Int), Var("i", Int))),
+				)),
+			)),
+			While(Bin(Gt, Var("total", Int), IntLit(100)), Block(
+				Assign(Var("total", Int), Bin(Sub, Var("total", Int), IntLit(100))),
+			)),
+			Call("printf", Str("%d\n"), Var("total", Int)),
+			Return(IntLit(0)),
+		)),
+	}
+	if errs := interlang.Validate(prog); len(errs) != 0 {
+		t.Fatalf("%v", errs)
+	}
+}
@@ -0,0 +1,248 @@
+// Package cfg 中間言語の関数の制御フローグラフ
+package cfg
+
+import (
+	"cape/interlang"
+	"fmt"
+)
+
+// Block 基本ブロック
+// 途中で分岐も合流もしない文の並び
+type Block struct {
+	ID int
+	// 順に実行される文
+	// 末尾にはReturn、Break、Continueが来ることがある
+	Stmts []*interlang.Node
+	// 分岐の条件
+	// nilでなければSuccs[0]が真、Succs[1]が偽の時の行き先になる
+	Cond *interlang.Node
+	// 後続のブロック
+	Succs []*Block
+	// 先行するブロック
+	Preds []*Block
+}
+
+// Graph 関数一つ分の制御フローグラフ
+type Graph struct {
+	// 関数の名前
+	Name  string
+	Entry *Block
+	// returnした時、または関数の末尾に達した時の行き先となる空のブロック
+	Exit *Block
+	// IDの順に並べた全てのブロック
+	Blocks []*Block
+}
+
+// Build 関数定義の本体から制御フローグラフを作る
+func Build(fn *interlang.FunctionDefineField) (*Graph, error) {
+	b := &builder{g: &Graph{}}
+	if ident, ok := fn.Ident.GetField().(*interlang.IdentField); ok {
+		b.g.Name = ident.S
+	}
+	b.g.Entry = b.newBlock()
+	b.g.Exit = b.newBlock()
+	b.cur = b.g.Entry
+	if err := b.stmt(fn.Block); err != nil {
+		return nil, err
+	}
+	if b.cur != nil {
+		b.jump(b.g.Exit)
+	}
+	return b.g, nil
+}
+
+// Reachable Entryから辿り着けるか
+func (g *Graph) Reachable() map[*Block]bool {
+	reachable := map[*Block]bool{}
+	var visit func(b *Block)
+	visit = func(b *Block) {
+		if reachable[b] {
+			return
+		}
+		reachable[b] = true
+		for _, succ := range b.Succs {
+			visit(succ)
+		}
+	}
+	visit(g.Entry)
+	return reachable
+}
+
+// Unreachable Entryから辿り着けない文を持つブロック
+func (g *Graph) Unreachable() []*Block {
+	reachable := g.Reachable()
+	var blocks []*Block
+	for _, b := range g.Blocks {
+		if !reachable[b] && (len(b.Stmts) != 0 || b.Cond != nil) {
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks
+}
+
+func addEdge(from, to *Block) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+// loop break、continueの行き先
+type loop struct {
+	label      string
+	breakTo    *Block
+	continueTo *Block
+}
+
+type builder struct {
+	g *Graph
+	// 文を追加しているブロック
+	// return、break、continueの直後はnilになる
+	cur   *Block
+	loops []*loop
+}
+
+func (b *builder) newBlock() *Block {
+	block := &Block{ID: len(b.g.Blocks)}
+	b.g.Blocks = append(b.g.Blocks, block)
+	return block
+}
+
+// current 文を追加するブロック
+// return等の後に続く文は、どこからも辿り着けない新しいブロックに置く
+func (b *builder) current() *Block {
+	if b.cur == nil {
+		b.cur = b.newBlock()
+	}
+	return b.cur
+}
+
+// jump 現在のブロックからtoへ無条件に移る
+func (b *builder) jump(to *Block) {
+	addEdge(b.current(), to)
+	b.cur = nil
+}
+
+// branch 現在のブロックの末尾でcondによって分岐する
+func (b *builder) branch(cond *interlang.Node, then, els *Block) {
+	cur := b.current()
+	cur.Cond = cond
+	addEdge(cur, then)
+	addEdge(cur, els)
+	b.cur = nil
+}
+
+func (b *builder) stmt(node *interlang.Node) error {
+	switch field := node.GetField().(type) {
+	case *interlang.BlockField:
+		for _, stmt := range field.Stmts {
+			if err := b.stmt(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *interlang.IfElseField:
+		then, join := b.newBlock(), b.newBlock()
+		els := join
+		if field.ElseBlock != nil {
+			els = b.newBlock()
+		}
+		b.branch(field.Cond, then, els)
+
+		b.cur = then
+		if err := b.stmt(field.IfBlock); err != nil {
+			return err
+		}
+		if b.cur != nil {
+			b.jump(join)
+		}
+		if field.ElseBlock != nil {
+			b.cur = els
+			if err := b.stmt(field.ElseBlock); err != nil {
+				return err
+			}
+			if b.cur != nil {
+				b.jump(join)
+			}
+		}
+		b.cur = join
+		return nil
+	case *interlang.WhileField:
+		header := b.newBlock()
+		b.jump(header)
+		body, after := b.newBlock(), b.newBlock()
+		b.cur = header
+		b.branch(field.Cond, body, after)
+		return b.loopBody(&loop{label: field.Label, breakTo: after, continueTo: header}, body, field.Block, header)
+	case *interlang.ForField:
+		if field.Init != nil {
+			b.current().Stmts = append(b.current().Stmts, field.Init)
+		}
+		header := b.newBlock()
+		b.jump(header)
+		body, after := b.newBlock(), b.newBlock()
+		b.cur = header
+		if field.Cond != nil {
+			b.branch(field.Cond, body, after)
+		} else {
+			b.jump(body)
+		}
+		// continueは更新式へ移る
+		update := header
+		if field.Loop != nil {
+			update = b.newBlock()
+			update.Stmts = append(update.Stmts, field.Loop)
+			addEdge(update, header)
+		}
+		return b.loopBody(&loop{label: field.Label, breakTo: after, continueTo: update}, body, field.Block, update)
+	case *interlang.ReturnField:
+		b.current().Stmts = append(b.current().Stmts, node)
+		b.jump(b.g.Exit)
+		return nil
+	case *interlang.BreakField:
+		l, err := b.findLoop(node, field.Label)
+		if err != nil {
+			return err
+		}
+		b.current().Stmts = append(b.current().Stmts, node)
+		b.jump(l.breakTo)
+		return nil
+	case *interlang.ContinueField:
+		l, err := b.findLoop(node, field.Label)
+		if err != nil {
+			return err
+		}
+		b.current().Stmts = append(b.current().Stmts, node)
+		b.jump(l.continueTo)
+		return nil
+	default:
+		b.current().Stmts = append(b.current().Stmts, node)
+		return nil
+	}
+}
+
+// loopBody ループの本体を作り、末尾からnextへ戻す
+// 作り終えるとループを抜けた先のブロックから続ける
+func (b *builder) loopBody(l *loop, body *Block, block *interlang.Node, next *Block) error {
+	b.loops = append(b.loops, l)
+	b.cur = body
+	if err := b.stmt(block); err != nil {
+		return err
+	}
+	if b.cur != nil {
+		b.jump(next)
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+	b.cur = l.breakTo
+	return nil
+}
+
+func (b *builder) findLoop(node *interlang.Node, label string) (*loop, error) {
+	for i := len(b.loops) - 1; i >= 0; i-- {
+		if label == "" || b.loops[i].label == label {
+			return b.loops[i], nil
+		}
+	}
+	if label == "" {
+		return nil, fmt.Errorf("%v: %v outside of a loop", node.GetSpan(), node.GetKind())
+	}
+	return nil, fmt.Errorf("%v: unknown loop label: %s", node.GetSpan(), label)
+}
@@ -0,0 +1,177 @@
+package cfg
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func buildGraph(t *testing.T, fn *interlang.Node) *Graph {
+	t.Helper()
+	g, err := Build(fn.GetField().(*interlang.FunctionDefineField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// edges 辿り着けるブロックの辺を"元->先"の形で並べる
+func edges(g *Graph) []string {
+	reachable := g.Reachable()
+	var es []string
+	for _, b := range g.Blocks {
+		if !reachable[b] {
+			continue
+		}
+		for _, succ := range b.Succs {
+			es = append(es, fmt.Sprintf("%d->%d", b.ID, succ.ID))
+		}
+	}
+	return es
+}
+
+func TestBuild(t *testing.T) {
+	x := build.Var("x", build.Int)
+	tests := []struct {
+		name   string
+		in     *interlang.Node
+		expect []string
+	}{
+		{
+			"straight",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("x", build.Int, build.IntLit(1)),
+				build.Return(x),
+			)),
+			[]string{"0->1"},
+		},
+		{
+			"if else",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.IfElse(x, build.Block(build.Return(build.IntLit(1))), build.Block(build.Assign(x, build.IntLit(2)))),
+				build.Return(x),
+			)),
+			// 0: entry, 1: exit, 2: then, 3: join, 4: else
+			[]string{"0->2", "0->4", "2->1", "3->1", "4->3"},
+		},
+		{
+			"while break",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.While(build.Bin(build.Lt, x, build.IntLit(10)), build.Block(
+					build.If(x, build.Block(build.Break())),
+					build.Assign(x, build.Bin(build.Add, x, build.IntLit(1))),
+				)),
+				build.Return(x),
+			)),
+			// 2: header, 3: body, 4: after, 5: then, 6: join
+			[]string{"0->2", "2->3", "2->4", "3->5", "3->6", "4->1", "5->4", "6->2"},
+		},
+		{
+			"for continue",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.For(
+					build.Assign(x, build.IntLit(0)),
+					build.Bin(build.Lt, x, build.IntLit(10)),
+					build.Assign(x, build.Bin(build.Add, x, build.IntLit(1))),
+					build.Block(build.Continue()),
+				),
+			)),
+			// 2: header, 3: body, 4: after, 5: update
+			[]string{"0->2", "2->3", "2->4", "3->5", "4->1", "5->2"},
+		},
+		{
+			"labeled break",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Labeled("outer", build.While(x, build.Block(
+					build.While(x, build.Block(build.BreakTo("outer"))),
+				))),
+			)),
+			// 2: outer header, 3: outer body, 4: outer after, 5: inner header, 6: inner body, 7: inner after
+			[]string{"0->2", "2->3", "2->4", "3->5", "4->1", "5->6", "5->7", "6->4", "7->2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := edges(buildGraph(t, tt.in))
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestBuildError(t *testing.T) {
+	fn := build.Func("main", build.Int, build.Params(), build.Block(build.Break()))
+	_, err := Build(fn.GetField().(*interlang.FunctionDefineField))
+	if err == nil {
+		t.Fatal("expected error for break outside of a loop")
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	x := build.Var("x", build.Int)
+	g := buildGraph(t, build.Func("main", build.Int, build.Params(), build.Block(
+		build.Return(build.IntLit(0)),
+		build.Assign(x, build.IntLit(1)),
+	)))
+	var got []string
+	for _, b := range g.Unreachable() {
+		for _, stmt := range b.Stmts {
+			got = append(got, Format(stmt))
+		}
+	}
+	if diff := cmp.Diff([]string{"x = 1"}, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+func TestDominators(t *testing.T) {
+	x := build.Var("x", build.Int)
+	g := buildGraph(t, build.Func("main", build.Int, build.Params(), build.Block(
+		build.IfElse(x, build.Block(build.Assign(x, build.IntLit(1))), build.Block(build.Assign(x, build.IntLit(2)))),
+		build.While(x, build.Block(build.Assign(x, build.Bin(build.Sub, x, build.IntLit(1))))),
+		build.Return(x),
+	)))
+	dom := g.Dominators()
+
+	// ブロックのIDから直接の支配者のIDへ
+	got := map[int]int{}
+	for _, b := range g.Blocks {
+		if idom := dom.Idom(b); idom != nil {
+			got[b.ID] = idom.ID
+		}
+	}
+	// 0: entry, 1: exit, 2: then, 3: join, 4: else, 5: header, 6: body, 7: after
+	expect := map[int]int{1: 7, 2: 0, 3: 0, 4: 0, 5: 3, 6: 5, 7: 5}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+	if !dom.Dominates(g.Entry, g.Exit) || dom.Dominates(g.Blocks[2], g.Blocks[3]) {
+		t.Fatal("unexpected dominance")
+	}
+}
+
+func TestDOT(t *testing.T) {
+	x := build.Var("x", build.Int)
+	g := buildGraph(t, build.Func("main", build.Int, build.Params(), build.Block(
+		build.If(build.Bin(build.Lt, x, build.IntLit(0)), build.Block(build.Return(build.Un(build.Neg, x)))),
+		build.Return(x),
+	)))
+	expect := `digraph "main" {
+	node [shape=box];
+	b0 [label="entry\l(x < 0) ?\l"];
+	b1 [label="exit\l"];
+	b2 [label="b2\lreturn -x\l"];
+	b3 [label="b3\lreturn x\l"];
+	b0 -> b2 [label="true"];
+	b0 -> b3 [label="false"];
+	b2 -> b1;
+	b3 -> b1;
+}
+`
+	if diff := cmp.Diff(expect, g.DOT()); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
@@ -0,0 +1,113 @@
+package cfg
+
+// DomTree 支配木
+// EntryからブロックBへの全ての経路がAを通る時、AはBを支配する
+type DomTree struct {
+	g *Graph
+	// ブロックのIDで引く直接の支配者
+	// Entryと、Entryから辿り着けないブロックはnil
+	idom []*Block
+	// 直接支配しているブロック
+	children [][]*Block
+}
+
+// Dominators 支配木を求める
+// Cooper, Harvey, Kennedy "A Simple, Fast Dominance Algorithm" の反復法を使う
+func (g *Graph) Dominators() *DomTree {
+	order := g.reversePostorder()
+	// 逆後順での位置
+	index := make([]int, len(g.Blocks))
+	for i := range index {
+		index[i] = -1
+	}
+	for i, b := range order {
+		index[b.ID] = i
+	}
+
+	idom := make([]*Block, len(g.Blocks))
+	idom[g.Entry.ID] = g.Entry
+	intersect := func(a, b *Block) *Block {
+		for a != b {
+			for index[a.ID] > index[b.ID] {
+				a = idom[a.ID]
+			}
+			for index[b.ID] > index[a.ID] {
+				b = idom[b.ID]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range order[1:] {
+			var newIdom *Block
+			for _, pred := range b.Preds {
+				// 処理していない、または辿り着けない先行ブロックは飛ばす
+				if idom[pred.ID] == nil {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = pred
+				} else {
+					newIdom = intersect(pred, newIdom)
+				}
+			}
+			if idom[b.ID] != newIdom {
+				idom[b.ID] = newIdom
+				changed = true
+			}
+		}
+	}
+	idom[g.Entry.ID] = nil
+
+	t := &DomTree{g: g, idom: idom, children: make([][]*Block, len(g.Blocks))}
+	for _, b := range g.Blocks {
+		if parent := idom[b.ID]; parent != nil {
+			t.children[parent.ID] = append(t.children[parent.ID], b)
+		}
+	}
+	return t
+}
+
+// Idom bの直接の支配者
+func (t *DomTree) Idom(b *Block) *Block {
+	return t.idom[b.ID]
+}
+
+// Children bが直接支配しているブロック
+func (t *DomTree) Children(b *Block) []*Block {
+	return t.children[b.ID]
+}
+
+// Dominates aがbを支配しているか
+// ブロックは自身を支配する
+func (t *DomTree) Dominates(a, b *Block) bool {
+	for cur := b; cur != nil; cur = t.idom[cur.ID] {
+		if cur == a {
+			return true
+		}
+	}
+	return false
+}
+
+// reversePostorder Entryから辿り着けるブロックを逆後順に並べる
+func (g *Graph) reversePostorder() []*Block {
+	visited := make([]bool, len(g.Blocks))
+	var post []*Block
+	var visit func(b *Block)
+	visit = func(b *Block) {
+		visited[b.ID] = true
+		for _, succ := range b.Succs {
+			if !visited[succ.ID] {
+				visit(succ)
+			}
+		}
+		post = append(post, b)
+	}
+	visit(g.Entry)
+	order := make([]*Block, len(post))
+	for i, b := range post {
+		order[len(post)-1-i] = b
+	}
+	return order
+}
@@ -0,0 +1,144 @@
+package cfg
+
+import (
+	"cape/interlang"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DOT graphvizのdot形式の文字列にする
+// `dot -Tpng` などで画像にできる
+func (g *Graph) DOT() string {
+	var sb strings.Builder
+	_ = g.WriteDOT(&sb)
+	return sb.String()
+}
+
+// WriteDOT graphvizのdot形式でwに書き出す
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %s {\n", strconv.Quote(g.Name))
+	sb.WriteString("\tnode [shape=box];\n")
+	for _, b := range g.Blocks {
+		fmt.Fprintf(&sb, "\tb%d [label=\"%s\"];\n", b.ID, g.blockLabel(b))
+	}
+	for _, b := range g.Blocks {
+		for i, succ := range b.Succs {
+			attr := ""
+			if b.Cond != nil {
+				if i == 0 {
+					attr = ` [label="true"]`
+				} else {
+					attr = ` [label="false"]`
+				}
+			}
+			fmt.Fprintf(&sb, "\tb%d -> b%d%s;\n", b.ID, succ.ID, attr)
+		}
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (g *Graph) blockLabel(b *Block) string {
+	var lines []string
+	switch b {
+	case g.Entry:
+		lines = append(lines, "entry")
+	case g.Exit:
+		lines = append(lines, "exit")
+	default:
+		lines = append(lines, fmt.Sprintf("b%d", b.ID))
+	}
+	for _, stmt := range b.Stmts {
+		lines = append(lines, Format(stmt))
+	}
+	if b.Cond != nil {
+		lines = append(lines, Format(b.Cond)+" ?")
+	}
+	// 行ごとにエスケープしてから左寄せの改行で繋ぐ
+	var sb strings.Builder
+	for _, l := range lines {
+		sb.WriteString(labelEscaper.Replace(l))
+		sb.WriteString("\\l")
+	}
+	return sb.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// operators 演算子のCでの表記
+var operators = map[interlang.Operation]string{
+	interlang.Add:    "+",
+	interlang.Sub:    "-",
+	interlang.Mul:    "*",
+	interlang.Div:    "/",
+	interlang.Mod:    "%",
+	interlang.And:    "&&",
+	interlang.Or:     "||",
+	interlang.Eq:     "==",
+	interlang.Ne:     "!=",
+	interlang.Lt:     "<",
+	interlang.Le:     "<=",
+	interlang.Gt:     ">",
+	interlang.Ge:     ">=",
+	interlang.BitAnd: "&",
+	interlang.BitOr:  "|",
+	interlang.BitXor: "^",
+	interlang.Shl:    "<<",
+	interlang.Shr:    ">>",
+	interlang.Neg:    "-",
+	interlang.Plus:   "+",
+	interlang.BitNot: "~",
+	interlang.Addr:   "&",
+}
+
+// Format 基本ブロックに置かれる文や式をCに似た一行の表記にする
+// 二項演算は常に括弧で囲む
+func Format(node *interlang.Node) string {
+	if node == nil {
+		return ""
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.IdentField:
+		return field.S
+	case *interlang.LiteralField:
+		if field.GetTType() == interlang.String {
+			return strconv.Quote(field.S)
+		}
+		return strconv.Itoa(field.I)
+	case *interlang.VariableDeclareField:
+		return fmt.Sprintf("%v %s", field.TType, Format(field.Ident))
+	case *interlang.VariableDefineField:
+		return fmt.Sprintf("%v %s = %s", field.TType, Format(field.Ident), Format(field.Value))
+	case *interlang.AssignField:
+		return fmt.Sprintf("%s = %s", Format(field.To), Format(field.Value))
+	case *interlang.BinaryField:
+		return fmt.Sprintf("(%s %s %s)", Format(field.LHS), operators[field.Operation], Format(field.RHS))
+	case *interlang.UnaryField:
+		return operators[field.Operation] + Format(field.Value)
+	case *interlang.NotField:
+		return "!" + Format(field.Value)
+	case *interlang.CallField:
+		return fmt.Sprintf("%s(%s)", Format(field.Ident), Format(field.Args))
+	case *interlang.MultipleField:
+		var values []string
+		for _, value := range field.Values {
+			values = append(values, Format(value))
+		}
+		return strings.Join(values, ", ")
+	case *interlang.ReturnField:
+		if field.Value == nil {
+			return "return"
+		}
+		return "return " + Format(field.Value)
+	case *interlang.BreakField:
+		return strings.TrimSpace("break " + field.Label)
+	case *interlang.ContinueField:
+		return strings.TrimSpace("continue " + field.Label)
+	default:
+		return node.GetKind().String()
+	}
+}
@@ -0,0 +1,114 @@
+package interlang
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func sampleTree() *Node {
+	x := NewNode(Ident, &IdentField{TType: Integer, S: "x"}).WithSpan(Span{File: "main.c", Line: 2, Col: 9})
+	return NewNode(FunctionDefine, &FunctionDefineField{
+		TType:  Integer,
+		Ident:  NewNode(Ident, &IdentField{S: "main"}),
+		Params: NewNode(Multiple, &MultipleField{TType: TTuple{Integer}, Values: []*Node{x}}),
+		Block: NewNode(Block, &BlockField{Stmts: []*Node{
+			NewNode(For, &ForField{
+				Init:  NewNode(Assign, &AssignField{To: x, Value: NewNode(Literal, &LiteralField{TType: Integer, I: 0})}),
+				Cond:  NewNode(Binary, &BinaryField{TType: Bool, Operation: Lt, LHS: x, RHS: NewNode(Literal, &LiteralField{TType: Integer, I: 10})}),
+				Loop:  NewNode(Unary, &UnaryField{TType: Integer, Operation: Neg, Value: x}),
+				Block: NewNode(Block, &BlockField{Stmts: []*Node{NewNode(Continue, &ContinueField{Label: "loop"})}}),
+				Label: "loop",
+			}),
+			NewNode(Return, &ReturnField{TType: Integer, Value: NewNode(Call, &CallField{
+				Ident: NewNode(Ident, &IdentField{S: "f"}),
+				Args:  NewNode(Multiple, &MultipleField{Values: []*Node{NewNode(Not, &NotField{Value: x})}}),
+			})}),
+		}}),
+	}).WithSpan(Span{File: "main.c", Line: 1, Col: 1})
+}
+
+func TestClone(t *testing.T) {
+	orig := sampleTree()
+	cloned := orig.Clone()
+	if diff := cmp.Diff(orig, cloned); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+	if !Equal(orig, cloned, EqualOptions{}) {
+		t.Fatal("clone is not equal to the original")
+	}
+
+	// 複製を書き換えても元の木は変わらない
+	cloned.GetField().(*FunctionDefineField).Block.GetField().(*BlockField).Stmts[0].GetField().(*ForField).Label = "other"
+	cloned.GetField().(*FunctionDefineField).Params.GetField().(*MultipleField).Values[0].GetField().(*IdentField).S = "y"
+	if diff := cmp.Diff(sampleTree(), orig); diff != "" {
+		t.Fatalf("original was modified: %v", diff)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	lit := func(i int) *Node {
+		return NewNode(Literal, &LiteralField{TType: Integer, I: i})
+	}
+	tests := []struct {
+		name   string
+		a, b   *Node
+		opts   EqualOptions
+		expect bool
+	}{
+		{"nil", nil, nil, EqualOptions{}, true},
+		{"nil and node", nil, lit(1), EqualOptions{}, false},
+		{"same", lit(1), lit(1), EqualOptions{}, true},
+		{"different value", lit(1), lit(2), EqualOptions{}, false},
+		{"different span", lit(1).WithSpan(Span{Line: 1}), lit(1).WithSpan(Span{Line: 2}), EqualOptions{}, false},
+		{"ignore span", lit(1).WithSpan(Span{Line: 1}), lit(1).WithSpan(Span{Line: 2}), EqualOptions{IgnoreSpan: true}, true},
+		{
+			"different type",
+			NewNode(Ident, &IdentField{TType: Integer, S: "x"}),
+			NewNode(Ident, &IdentField{S: "x"}),
+			EqualOptions{},
+			false,
+		},
+		{
+			"ignore type",
+			NewNode(Ident, &IdentField{TType: Integer, S: "x"}),
+			NewNode(Ident, &IdentField{S: "x"}),
+			EqualOptions{IgnoreTType: true},
+			true,
+		},
+		{
+			"tuple type",
+			NewNode(Multiple, &MultipleField{TType: TTuple{Integer, nil}, Values: []*Node{lit(1), lit(2)}}),
+			NewNode(Multiple, &MultipleField{TType: TTuple{Integer, nil}, Values: []*Node{lit(1), lit(2)}}),
+			EqualOptions{},
+			true,
+		},
+		{
+			"different operation",
+			NewNode(Binary, &BinaryField{TType: Integer, Operation: Add, LHS: lit(1), RHS: lit(2)}),
+			NewNode(Binary, &BinaryField{TType: Integer, Operation: Sub, LHS: lit(1), RHS: lit(2)}),
+			EqualOptions{},
+			false,
+		},
+		{"tree", sampleTree(), sampleTree(), EqualOptions{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b, tt.opts); got != tt.expect {
+				t.Fatalf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	var got []string
+	Walk(sampleTree(), func(n *Node) bool {
+		got = append(got, n.GetKind().String())
+		// for文の中は辿らない
+		return n.GetKind() != For
+	})
+	expect := []string{"FunctionDefine", "Ident", "Multiple", "Ident", "Block", "For", "Return", "Call", "Ident", "Multiple", "Not", "Ident"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
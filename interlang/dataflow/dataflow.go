@@ -0,0 +1,474 @@
+// Package dataflow 制御フローグラフの上で変数の代入と使用を調べる
+//
+// 変数は名前で区別する
+// 入れ子のブロックで同じ名前の変数を宣言し直した場合も同じ変数として扱う
+package dataflow
+
+import (
+	"cape/interlang"
+	"cape/interlang/cfg"
+	"fmt"
+	"sort"
+)
+
+// Kind 警告の種類
+type Kind int
+
+const (
+	_ Kind = iota
+	// 代入される前に読まれるかもしれない
+	UseBeforeAssign
+	// 宣言したが一度も読まれない局所変数
+	UnusedVariable
+	// 一度も読まれない仮引数
+	UnusedParameter
+	// 代入した値が読まれる前に上書きされるか、そのまま捨てられる
+	DeadStore
+)
+
+// Warning 解析で見つかった問題
+type Warning struct {
+	Kind
+	Span interlang.Span
+	Name string
+}
+
+// Message 位置を除いた警告文
+func (w *Warning) Message() string {
+	switch w.Kind {
+	case UseBeforeAssign:
+		return fmt.Sprintf("%s may be used before assignment", w.Name)
+	case UnusedVariable:
+		return fmt.Sprintf("%s declared and not used", w.Name)
+	case UnusedParameter:
+		return fmt.Sprintf("parameter %s is not used", w.Name)
+	case DeadStore:
+		return fmt.Sprintf("value assigned to %s is never used", w.Name)
+	default:
+		return fmt.Sprintf("unknown warning: %s", w.Name)
+	}
+}
+
+func (w *Warning) Error() string {
+	return fmt.Sprintf("%v: %s", w.Span, w.Message())
+}
+
+// Result 関数一つ分の解析結果
+type Result struct {
+	Warnings []*Warning
+	// 代入される前に読まれるかもしれない変数のVariableDeclare
+	// Cでは不定値を読むことになるので、他の言語では明示的な初期化が要る
+	Uninitialized []*interlang.Node
+}
+
+// Analyze 関数定義を解析する
+func Analyze(fn *interlang.FunctionDefineField) (*Result, error) {
+	g, err := cfg.Build(fn)
+	if err != nil {
+		return nil, err
+	}
+	a := &analyzer{
+		g:      g,
+		locals: map[string][]*interlang.Node{},
+		params: map[string]*interlang.Node{},
+		reads:  map[string]bool{},
+		result: &Result{},
+	}
+	a.collectParams(fn.Params)
+	a.collect(fn.Block)
+
+	a.definiteAssignment()
+	a.liveness()
+	a.unused()
+
+	sort.SliceStable(a.result.Warnings, func(i, j int) bool {
+		si, sj := a.result.Warnings[i].Span, a.result.Warnings[j].Span
+		if si.Line != sj.Line {
+			return si.Line < sj.Line
+		}
+		return si.Col < sj.Col
+	})
+	return a.result, nil
+}
+
+// set 変数名の集合
+type set map[string]bool
+
+func (s set) clone() set {
+	c := set{}
+	for name := range s {
+		c[name] = true
+	}
+	return c
+}
+
+func (s set) equal(other set) bool {
+	if len(s) != len(other) {
+		return false
+	}
+	for name := range s {
+		if !other[name] {
+			return false
+		}
+	}
+	return true
+}
+
+type analyzer struct {
+	g *cfg.Graph
+	// 局所変数の名前から、その名前を宣言しているノード
+	locals map[string][]*interlang.Node
+	// 仮引数の名前から識別子のノード
+	params map[string]*interlang.Node
+	// 一度でも読まれる変数
+	reads  set
+	result *Result
+}
+
+func (a *analyzer) warn(kind Kind, node *interlang.Node, name string) {
+	a.result.Warnings = append(a.result.Warnings, &Warning{Kind: kind, Span: node.GetSpan(), Name: name})
+}
+
+// isVar 解析の対象となる変数か
+// 大域変数は他の関数から代入されうるので対象にしない
+func (a *analyzer) isVar(name string) bool {
+	_, local := a.locals[name]
+	_, param := a.params[name]
+	return local || param
+}
+
+func identName(node *interlang.Node) string {
+	return node.GetField().(*interlang.IdentField).S
+}
+
+// collectParams 仮引数はParamの並び
+func (a *analyzer) collectParams(node *interlang.Node) {
+	if node == nil {
+		return
+	}
+	for _, param := range node.GetField().(*interlang.MultipleField).Values {
+		if field, ok := param.GetField().(*interlang.ParamField); ok {
+			a.params[identName(field.Ident)] = field.Ident
+		}
+	}
+}
+
+// collect 局所変数の宣言と読まれる変数を集める
+func (a *analyzer) collect(node *interlang.Node) {
+	if node == nil {
+		return
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.VariableDeclareField:
+		name := identName(field.Ident)
+		a.locals[name] = append(a.locals[name], node)
+	case *interlang.VariableDefineField:
+		name := identName(field.Ident)
+		a.locals[name] = append(a.locals[name], node)
+		a.collect(field.Value)
+	case *interlang.IdentField:
+		a.reads[field.S] = true
+	case *interlang.AssignField:
+		if field.To.GetKind() != interlang.Ident {
+			a.collect(field.To)
+		}
+		a.collect(field.Value)
+	case *interlang.BlockField:
+		for _, stmt := range field.Stmts {
+			a.collect(stmt)
+		}
+	case *interlang.IfElseField:
+		a.collect(field.Cond)
+		a.collect(field.IfBlock)
+		a.collect(field.ElseBlock)
+	case *interlang.WhileField:
+		a.collect(field.Cond)
+		a.collect(field.Block)
+	case *interlang.ForField:
+		a.collect(field.Init)
+		a.collect(field.Cond)
+		a.collect(field.Loop)
+		a.collect(field.Block)
+	case *interlang.ReturnField:
+		a.collect(field.Value)
+	case *interlang.BinaryField:
+		a.collect(field.LHS)
+		a.collect(field.RHS)
+	case *interlang.UnaryField:
+		a.collect(field.Value)
+	case *interlang.NotField:
+		a.collect(field.Value)
+	case *interlang.CallField:
+		a.collect(field.Args)
+	case *interlang.MultipleField:
+		for _, value := range field.Values {
+			a.collect(value)
+		}
+	}
+}
+
+// visitor 基本ブロックの文を実行順に辿る
+type visitor struct {
+	// 変数が読まれた
+	read func(ident *interlang.Node, name string)
+	// 変数に代入された
+	write func(node *interlang.Node, name string)
+	// 初期値なしで宣言された
+	declare func(node *interlang.Node, name string)
+	// アドレスを渡した先で読み書きされうる(scanfの&xやfgetsの配列)
+	escape func(ident *interlang.Node, name string)
+}
+
+func (v *visitor) visit(node *interlang.Node) {
+	if node == nil {
+		return
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.VariableDeclareField:
+		v.declare(node, identName(field.Ident))
+	case *interlang.VariableDefineField:
+		v.visit(field.Value)
+		v.write(node, identName(field.Ident))
+	case *interlang.IdentField:
+		v.read(node, field.S)
+	case *interlang.AssignField:
+		if field.To.GetKind() != interlang.Ident {
+			v.visit(field.To)
+			v.visit(field.Value)
+			return
+		}
+		v.visit(field.Value)
+		v.write(node, identName(field.To))
+	case *interlang.ReturnField:
+		v.visit(field.Value)
+	case *interlang.BinaryField:
+		v.visit(field.LHS)
+		v.visit(field.RHS)
+	case *interlang.UnaryField:
+		if field.Operation == interlang.Addr && field.Value.GetKind() == interlang.Ident {
+			v.escape(field.Value, identName(field.Value))
+			return
+		}
+		v.visit(field.Value)
+	case *interlang.NotField:
+		v.visit(field.Value)
+	case *interlang.CallField:
+		if field.Args == nil {
+			return
+		}
+		for i, arg := range field.Args.GetField().(*interlang.MultipleField).Values {
+			// fgetsは最初の引数の配列へ書き込む
+			if i == 0 && identName(field.Ident) == "fgets" && arg.GetKind() == interlang.Ident {
+				v.escape(arg, identName(arg))
+				continue
+			}
+			v.visit(arg)
+		}
+	case *interlang.MultipleField:
+		for _, value := range field.Values {
+			v.visit(value)
+		}
+	}
+}
+
+// definiteAssignment 全ての経路で代入済みの変数を前向きに求め、
+// 代入済みでない変数を読んでいる所を探す
+func (a *analyzer) definiteAssignment() {
+	reachable := a.g.Reachable()
+	universe := set{}
+	for name := range a.locals {
+		universe[name] = true
+	}
+	entry := set{}
+	for name := range a.params {
+		universe[name] = true
+		entry[name] = true
+	}
+
+	transfer := func(b *cfg.Block, assigned set, report func(*interlang.Node, string)) {
+		v := &visitor{
+			read: func(ident *interlang.Node, name string) {
+				if universe[name] && !assigned[name] && report != nil {
+					report(ident, name)
+				}
+			},
+			write:   func(_ *interlang.Node, name string) { assigned[name] = true },
+			declare: func(_ *interlang.Node, name string) { delete(assigned, name) },
+			// 渡した先で代入されるとみなす
+			escape: func(_ *interlang.Node, name string) { assigned[name] = true },
+		}
+		for _, stmt := range b.Stmts {
+			v.visit(stmt)
+		}
+		v.visit(b.Cond)
+	}
+
+	out := make([]set, len(a.g.Blocks))
+	for _, b := range a.g.Blocks {
+		out[b.ID] = universe.clone()
+	}
+	in := func(b *cfg.Block) set {
+		if b == a.g.Entry {
+			return entry.clone()
+		}
+		var s set
+		for _, pred := range b.Preds {
+			if !reachable[pred] {
+				continue
+			}
+			if s == nil {
+				s = out[pred.ID].clone()
+				continue
+			}
+			for name := range s {
+				if !out[pred.ID][name] {
+					delete(s, name)
+				}
+			}
+		}
+		if s == nil {
+			s = set{}
+		}
+		return s
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range a.g.Blocks {
+			if !reachable[b] {
+				continue
+			}
+			assigned := in(b)
+			transfer(b, assigned, nil)
+			if !assigned.equal(out[b.ID]) {
+				out[b.ID] = assigned
+				changed = true
+			}
+		}
+	}
+
+	uninitialized := set{}
+	for _, b := range a.g.Blocks {
+		if !reachable[b] {
+			continue
+		}
+		transfer(b, in(b), func(ident *interlang.Node, name string) {
+			a.warn(UseBeforeAssign, ident, name)
+			uninitialized[name] = true
+		})
+	}
+	for name := range uninitialized {
+		for _, decl := range a.locals[name] {
+			if decl.GetKind() == interlang.VariableDeclare {
+				a.result.Uninitialized = append(a.result.Uninitialized, decl)
+			}
+		}
+	}
+	sort.SliceStable(a.result.Uninitialized, func(i, j int) bool {
+		return identName(a.result.Uninitialized[i].GetField().(*interlang.VariableDeclareField).Ident) <
+			identName(a.result.Uninitialized[j].GetField().(*interlang.VariableDeclareField).Ident)
+	})
+}
+
+// liveness この先で読まれる変数を後ろ向きに求め、読まれない値の代入を探す
+func (a *analyzer) liveness() {
+	reachable := a.g.Reachable()
+
+	// transfer 基本ブロックの末尾で生きている変数から先頭で生きている変数を求める
+	transfer := func(b *cfg.Block, live set, report func(*interlang.Node, string)) {
+		// 文の中では代入先より先に右辺が評価されるので、
+		// 文ごとに代入と読み込みを集めてから後ろ向きに反映する
+		step := func(stmt *interlang.Node) {
+			var writes []*interlang.Node
+			var names []string
+			var reads []string
+			v := &visitor{
+				read: func(_ *interlang.Node, name string) { reads = append(reads, name) },
+				write: func(node *interlang.Node, name string) {
+					writes = append(writes, node)
+					names = append(names, name)
+				},
+				declare: func(_ *interlang.Node, name string) { delete(live, name) },
+				// 渡した先で読まれるかもしれないので、それまでの代入は生きている
+				escape: func(_ *interlang.Node, name string) { reads = append(reads, name) },
+			}
+			v.visit(stmt)
+			for i, name := range names {
+				if a.isVar(name) && !live[name] && report != nil {
+					report(writes[i], name)
+				}
+				delete(live, name)
+			}
+			for _, name := range reads {
+				live[name] = true
+			}
+		}
+		if b.Cond != nil {
+			step(b.Cond)
+		}
+		for i := len(b.Stmts) - 1; i >= 0; i-- {
+			step(b.Stmts[i])
+		}
+	}
+
+	in := make([]set, len(a.g.Blocks))
+	for _, b := range a.g.Blocks {
+		in[b.ID] = set{}
+	}
+	out := func(b *cfg.Block) set {
+		s := set{}
+		for _, succ := range b.Succs {
+			for name := range in[succ.ID] {
+				s[name] = true
+			}
+		}
+		return s
+	}
+	for changed := true; changed; {
+		changed = false
+		for i := len(a.g.Blocks) - 1; i >= 0; i-- {
+			b := a.g.Blocks[i]
+			live := out(b)
+			transfer(b, live, nil)
+			if !live.equal(in[b.ID]) {
+				in[b.ID] = live
+				changed = true
+			}
+		}
+	}
+
+	for _, b := range a.g.Blocks {
+		if !reachable[b] {
+			continue
+		}
+		transfer(b, out(b), func(node *interlang.Node, name string) {
+			// 一度も読まれない変数は宣言の方で警告する
+			if a.reads[name] {
+				a.warn(DeadStore, node, name)
+			}
+		})
+	}
+}
+
+// unused 一度も読まれない局所変数と仮引数を探す
+func (a *analyzer) unused() {
+	var names []string
+	for name := range a.locals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !a.reads[name] {
+			a.warn(UnusedVariable, a.locals[name][0], name)
+		}
+	}
+	names = nil
+	for name := range a.params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !a.reads[name] {
+			a.warn(UnusedParameter, a.params[name], name)
+		}
+	}
+}
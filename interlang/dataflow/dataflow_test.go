@@ -0,0 +1,168 @@
+package dataflow
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	x := build.Var("x", build.Int)
+	y := build.Var("y", build.Int)
+	tests := []struct {
+		name          string
+		in            *interlang.Node
+		expect        []string
+		uninitialized []string
+	}{
+		{
+			"clean",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("x", build.Int, build.IntLit(1)),
+				build.Return(x),
+			)),
+			nil,
+			nil,
+		},
+		{
+			"use before assignment on one path",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Declare("x", build.Int),
+				build.If(build.Call("rand"), build.Block(build.Assign(x, build.IntLit(1)))),
+				build.Return(x),
+			)),
+			[]string{"x may be used before assignment"},
+			[]string{"x"},
+		},
+		{
+			"assigned on both paths",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Declare("x", build.Int),
+				build.IfElse(build.Call("rand"),
+					build.Block(build.Assign(x, build.IntLit(1))),
+					build.Block(build.Assign(x, build.IntLit(2))),
+				),
+				build.Return(x),
+			)),
+			nil,
+			nil,
+		},
+		{
+			"loop carried",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Declare("x", build.Int),
+				build.Define("y", build.Int, build.IntLit(0)),
+				build.While(build.Bin(build.Lt, y, build.IntLit(10)), build.Block(
+					build.Assign(y, build.Bin(build.Add, y, x)),
+					build.Assign(x, build.IntLit(1)),
+				)),
+				build.Return(y),
+			)),
+			[]string{"x may be used before assignment"},
+			[]string{"x"},
+		},
+		{
+			"dead store and unused",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("x", build.Int, build.IntLit(1)),
+				build.Define("y", build.Int, build.IntLit(2)),
+				build.Assign(x, build.IntLit(3)),
+				build.Return(x),
+			)),
+			[]string{"value assigned to x is never used", "y declared and not used"},
+			nil,
+		},
+		{
+			"unused parameter",
+			build.Func("f", build.Int, build.Params(build.Param("a", build.Int), build.Param("b", build.Int)), build.Block(
+				build.Return(build.Var("a", build.Int)),
+			)),
+			[]string{"parameter b is not used"},
+			nil,
+		},
+		{
+			"assigned through address",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Declare("x", build.Int),
+				build.Call("scanf", build.Str("%d"), build.Un(build.Addr, x)),
+				build.Call("printf", build.Str("%d"), x),
+				build.Return(build.IntLit(0)),
+			)),
+			nil,
+			nil,
+		},
+		{
+			"kept when scanf fails",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("x", build.Int, build.IntLit(-1)),
+				build.Call("scanf", build.Str("%d"), build.Un(build.Addr, x)),
+				build.Return(x),
+			)),
+			nil,
+			nil,
+		},
+		{
+			"fgets buffer",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Declare("s", interlang.TArray{Elem: interlang.Integer, Len: 80}),
+				build.Call("fgets", build.Id("s"), build.IntLit(80), build.Id("stdin")),
+				build.Call("puts", build.Id("s")),
+				build.Return(build.IntLit(0)),
+			)),
+			nil,
+			nil,
+		},
+		{
+			"global",
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Assign(build.Id("count"), build.IntLit(1)),
+				build.Return(build.IntLit(0)),
+			)),
+			nil,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Analyze(tt.in.GetField().(*interlang.FunctionDefineField))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, w := range result.Warnings {
+				got = append(got, w.Message())
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+			var uninitialized []string
+			for _, decl := range result.Uninitialized {
+				uninitialized = append(uninitialized, identName(decl.GetField().(*interlang.VariableDeclareField).Ident))
+			}
+			if diff := cmp.Diff(tt.uninitialized, uninitialized); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestWarningSpan(t *testing.T) {
+	x := build.Var("x", build.Int)
+	use := build.Var("x", build.Int).WithSpan(interlang.Span{File: "main.c", Line: 3, Col: 12, EndLine: 3, EndCol: 13})
+	fn := build.Func("main", build.Int, build.Params(), build.Block(
+		build.Declare("x", build.Int),
+		build.Return(use),
+		build.Assign(x, build.IntLit(1)),
+	))
+	result, err := Analyze(fn.GetField().(*interlang.FunctionDefineField))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", result.Warnings)
+	}
+	if got := result.Warnings[0].Error(); got != "main.c:3:12: x may be used before assignment" {
+		t.Fatal(got)
+	}
+}
@@ -0,0 +1,467 @@
+package eval
+
+import (
+	"bytes"
+	"cape/interlang"
+	"fmt"
+	"math"
+)
+
+// Result プログラムを実行した結果
+type Result struct {
+	Stdout   string
+	ExitCode int
+}
+
+// Run 中間言語のプログラムをmainから直接実行する
+// 整数はCのintと同じく32bitとして扱う
+// 標準出力とmainの戻り値から求めた終了コードを返す
+func Run(nodes []*interlang.Node) (*Result, error) {
+	var stdout bytes.Buffer
+	in := &interpreter{
+		stdout:  &stdout,
+		funcs:   map[string]*interlang.FunctionDefineField{},
+		globals: newScope(nil),
+	}
+	for _, node := range nodes {
+		if err := in.toplevel(node); err != nil {
+			return nil, err
+		}
+	}
+	main, ok := in.funcs["main"]
+	if !ok {
+		return nil, fmt.Errorf("function main is not defined")
+	}
+	rv, err := in.call(main, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Stdout: stdout.String(), ExitCode: int(uint8(rv.i))}, nil
+}
+
+type valueKind int
+
+const (
+	_ valueKind = iota
+	intValue
+	stringValue
+)
+
+type value struct {
+	kind valueKind
+	i    int32
+	s    string
+}
+
+func newInt(i int32) value {
+	return value{kind: intValue, i: i}
+}
+
+func newBool(b bool) value {
+	if b {
+		return newInt(1)
+	}
+	return newInt(0)
+}
+
+func (v value) truthy() bool {
+	if v.kind == stringValue {
+		return true
+	}
+	return v.i != 0
+}
+
+type scope struct {
+	vars   map[string]*value
+	parent *scope
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{vars: map[string]*value{}, parent: parent}
+}
+
+func (s *scope) lookup(name string) (*value, bool) {
+	for cur := s; cur != nil; cur = cur.parent {
+		if v, ok := cur.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// control 文の実行後に制御がどこへ移るか
+type control int
+
+const (
+	next control = iota
+	returned
+	broke
+	continued
+)
+
+type interpreter struct {
+	stdout  *bytes.Buffer
+	funcs   map[string]*interlang.FunctionDefineField
+	globals *scope
+
+	// 直近のreturnで返された値
+	rv value
+	// 直近のbreak、continueの対象のラベル
+	target string
+}
+
+// targets 直近のbreak、continueがラベルlabelのループを対象としているか
+func (in *interpreter) targets(label string) bool {
+	return in.target == "" || in.target == label
+}
+
+func errorf(node *interlang.Node, format string, a ...any) error {
+	return fmt.Errorf("%v: %s", node.GetSpan(), fmt.Sprintf(format, a...))
+}
+
+func identName(node *interlang.Node) string {
+	return node.GetField().(*interlang.IdentField).S
+}
+
+func (in *interpreter) toplevel(node *interlang.Node) error {
+	switch field := node.GetField().(type) {
+	case *interlang.FunctionDefineField:
+		in.funcs[identName(field.Ident)] = field
+		return nil
+	case *interlang.FunctionDeclareField, *interlang.TypeDefineField:
+		return nil
+	case *interlang.VariableDeclareField, *interlang.VariableDefineField:
+		_, err := in.stmt(node, in.globals)
+		return err
+	default:
+		return errorf(node, "unexpected toplevel node: %v", node.GetKind())
+	}
+}
+
+func (in *interpreter) call(fn *interlang.FunctionDefineField, args []value) (value, error) {
+	env := newScope(in.globals)
+	var params []*interlang.Node
+	if fn.Params != nil {
+		params = fn.Params.GetField().(*interlang.MultipleField).Values
+	}
+	if len(args) > len(params) {
+		return value{}, errorf(fn.Ident, "too many arguments to %s", identName(fn.Ident))
+	}
+	for i, param := range params {
+		field := param.GetField().(*interlang.ParamField)
+		if i < len(args) {
+			v := args[i]
+			env.vars[identName(field.Ident)] = &v
+			continue
+		}
+		// 省略された引数は既定値を大域変数の環境で評価する
+		if field.Default == nil {
+			return value{}, errorf(fn.Ident, "too few arguments to %s", identName(fn.Ident))
+		}
+		v, err := in.expr(field.Default, in.globals)
+		if err != nil {
+			return value{}, err
+		}
+		env.vars[identName(field.Ident)] = &v
+	}
+	ctl, err := in.stmt(fn.Block, env)
+	if err != nil {
+		return value{}, err
+	}
+	if ctl == returned {
+		return in.rv, nil
+	}
+	// returnせずに抜けた
+	return newInt(0), nil
+}
+
+func (in *interpreter) stmt(node *interlang.Node, env *scope) (control, error) {
+	switch field := node.GetField().(type) {
+	case *interlang.BlockField:
+		inner := newScope(env)
+		for _, stmt := range field.Stmts {
+			ctl, err := in.stmt(stmt, inner)
+			if err != nil || ctl != next {
+				return ctl, err
+			}
+		}
+		return next, nil
+	case *interlang.VariableDeclareField:
+		v := newInt(0)
+		env.vars[identName(field.Ident)] = &v
+		return next, nil
+	case *interlang.VariableDefineField:
+		v, err := in.expr(field.Value, env)
+		if err != nil {
+			return next, err
+		}
+		env.vars[identName(field.Ident)] = &v
+		return next, nil
+	case *interlang.ReturnField:
+		in.rv = newInt(0)
+		if field.Value != nil {
+			v, err := in.expr(field.Value, env)
+			if err != nil {
+				return next, err
+			}
+			in.rv = v
+		}
+		return returned, nil
+	case *interlang.BreakField:
+		in.target = field.Label
+		return broke, nil
+	case *interlang.ContinueField:
+		in.target = field.Label
+		return continued, nil
+	case *interlang.IfElseField:
+		cond, err := in.expr(field.Cond, env)
+		if err != nil {
+			return next, err
+		}
+		if cond.truthy() {
+			return in.stmt(field.IfBlock, env)
+		}
+		if field.ElseBlock != nil {
+			return in.stmt(field.ElseBlock, env)
+		}
+		return next, nil
+	case *interlang.WhileField:
+		for {
+			cond, err := in.expr(field.Cond, env)
+			if err != nil {
+				return next, err
+			}
+			if !cond.truthy() {
+				return next, nil
+			}
+			ctl, err := in.stmt(field.Block, env)
+			if err != nil {
+				return next, err
+			}
+			switch {
+			case ctl == broke && in.targets(field.Label):
+				return next, nil
+			case ctl == continued && in.targets(field.Label):
+			case ctl != next:
+				return ctl, nil
+			}
+		}
+	case *interlang.ForField:
+		// for文の初期化で宣言した変数はfor文の中だけで見える
+		loopEnv := newScope(env)
+		if field.Init != nil {
+			if _, err := in.stmt(field.Init, loopEnv); err != nil {
+				return next, err
+			}
+		}
+		for {
+			if field.Cond != nil {
+				cond, err := in.expr(field.Cond, loopEnv)
+				if err != nil {
+					return next, err
+				}
+				if !cond.truthy() {
+					return next, nil
+				}
+			}
+			ctl, err := in.stmt(field.Block, loopEnv)
+			if err != nil {
+				return next, err
+			}
+			switch {
+			case ctl == broke && in.targets(field.Label):
+				return next, nil
+			case ctl == continued && in.targets(field.Label):
+			case ctl != next:
+				return ctl, nil
+			}
+			if field.Loop != nil {
+				if _, err := in.expr(field.Loop, loopEnv); err != nil {
+					return next, err
+				}
+			}
+		}
+	default:
+		_, err := in.expr(node, env)
+		return next, err
+	}
+}
+
+func (in *interpreter) expr(node *interlang.Node, env *scope) (value, error) {
+	switch field := node.GetField().(type) {
+	case *interlang.LiteralField:
+		switch field.GetTType() {
+		case interlang.Integer:
+			return newInt(int32(field.I)), nil
+		case interlang.String:
+			return value{kind: stringValue, s: field.S}, nil
+		default:
+			return value{}, errorf(node, "unsupported literal: %v", field.GetTType())
+		}
+	case *interlang.IdentField:
+		v, ok := env.lookup(field.S)
+		if !ok {
+			return value{}, errorf(node, "undefined variable: %s", field.S)
+		}
+		return *v, nil
+	case *interlang.AssignField:
+		name := identName(field.To)
+		dst, ok := env.lookup(name)
+		if !ok {
+			return value{}, errorf(node, "undefined variable: %s", name)
+		}
+		v, err := in.expr(field.Value, env)
+		if err != nil {
+			return value{}, err
+		}
+		*dst = v
+		return v, nil
+	case *interlang.NotField:
+		v, err := in.expr(field.Value, env)
+		if err != nil {
+			return value{}, err
+		}
+		return newBool(!v.truthy()), nil
+	case *interlang.UnaryField:
+		v, err := in.expr(field.Value, env)
+		if err != nil {
+			return value{}, err
+		}
+		if v.kind != intValue {
+			return value{}, errorf(node, "unsupported operand for %v", field.Operation)
+		}
+		switch field.Operation {
+		case interlang.Neg:
+			return newInt(-v.i), nil
+		case interlang.Plus:
+			return v, nil
+		case interlang.BitNot:
+			return newInt(^v.i), nil
+		default:
+			return value{}, errorf(node, "unsupported operation: %v", field.Operation)
+		}
+	case *interlang.BinaryField:
+		return in.binary(node, field, env)
+	case *interlang.CallField:
+		return in.callExpr(node, field, env)
+	default:
+		return value{}, errorf(node, "unexpected expression: %v", node.GetKind())
+	}
+}
+
+func (in *interpreter) binary(node *interlang.Node, field *interlang.BinaryField, env *scope) (value, error) {
+	lhs, err := in.expr(field.LHS, env)
+	if err != nil {
+		return value{}, err
+	}
+	// 短絡評価
+	switch field.Operation {
+	case interlang.And:
+		if !lhs.truthy() {
+			return newInt(0), nil
+		}
+		rhs, err := in.expr(field.RHS, env)
+		if err != nil {
+			return value{}, err
+		}
+		return newBool(rhs.truthy()), nil
+	case interlang.Or:
+		if lhs.truthy() {
+			return newInt(1), nil
+		}
+		rhs, err := in.expr(field.RHS, env)
+		if err != nil {
+			return value{}, err
+		}
+		return newBool(rhs.truthy()), nil
+	}
+
+	rhs, err := in.expr(field.RHS, env)
+	if err != nil {
+		return value{}, err
+	}
+	if lhs.kind != intValue || rhs.kind != intValue {
+		return value{}, errorf(node, "unsupported operands for %v", field.Operation)
+	}
+	l, r := lhs.i, rhs.i
+	switch field.Operation {
+	case interlang.Add:
+		return newInt(l + r), nil
+	case interlang.Sub:
+		return newInt(l - r), nil
+	case interlang.Mul:
+		return newInt(l * r), nil
+	case interlang.Div, interlang.Mod:
+		if r == 0 {
+			return value{}, errorf(node, "division by zero")
+		}
+		if l == math.MinInt32 && r == -1 {
+			return value{}, errorf(node, "integer overflow")
+		}
+		if field.Operation == interlang.Div {
+			return newInt(l / r), nil
+		}
+		return newInt(l % r), nil
+	case interlang.Eq:
+		return newBool(l == r), nil
+	case interlang.Ne:
+		return newBool(l != r), nil
+	case interlang.Lt:
+		return newBool(l < r), nil
+	case interlang.Le:
+		return newBool(l <= r), nil
+	case interlang.Gt:
+		return newBool(l > r), nil
+	case interlang.Ge:
+		return newBool(l >= r), nil
+	case interlang.BitAnd:
+		return newInt(l & r), nil
+	case interlang.BitOr:
+		return newInt(l | r), nil
+	case interlang.BitXor:
+		return newInt(l ^ r), nil
+	case interlang.Shl, interlang.Shr:
+		if r < 0 || r >= 32 {
+			return value{}, errorf(node, "shift count out of range: %d", r)
+		}
+		// 負の数の右シフトは算術シフトになる
+		if field.Operation == interlang.Shl {
+			return newInt(int32(uint32(l) << r)), nil
+		}
+		return newInt(l >> r), nil
+	default:
+		return value{}, errorf(node, "unsupported operation: %v", field.Operation)
+	}
+}
+
+func (in *interpreter) callExpr(node *interlang.Node, field *interlang.CallField, env *scope) (value, error) {
+	name := identName(field.Ident)
+	var args []value
+	if field.Args != nil {
+		for _, arg := range field.Args.GetField().(*interlang.MultipleField).Values {
+			v, err := in.expr(arg, env)
+			if err != nil {
+				return value{}, err
+			}
+			args = append(args, v)
+		}
+	}
+
+	if fn, ok := in.funcs[name]; ok {
+		return in.call(fn, args)
+	}
+	switch name {
+	case "printf":
+		if len(args) == 0 || args[0].kind != stringValue {
+			return value{}, errorf(node, "printf requires a format string")
+		}
+		s, err := sprintf(args[0].s, args[1:])
+		if err != nil {
+			return value{}, errorf(node, "%v", err)
+		}
+		in.stdout.WriteString(s)
+		return newInt(int32(len(s))), nil
+	default:
+		return value{}, errorf(node, "undefined function: %s", name)
+	}
+}
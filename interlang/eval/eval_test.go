@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     []*interlang.Node
+		expect *Result
+	}{
+		{
+			"return",
+			[]*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(build.Return(build.IntLit(32))))},
+			&Result{ExitCode: 32},
+		},
+		{
+			"exit code wraps",
+			[]*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(build.Return(build.IntLit(-1))))},
+			&Result{ExitCode: 255},
+		},
+		{
+			"printf",
+			[]*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(
+				build.Call("printf", build.Str("%d %s %05.2d|%-3d|%x %c %%\n"), build.IntLit(-7), build.Str("hi"), build.IntLit(3), build.IntLit(4), build.IntLit(-1), build.IntLit(65)),
+				build.Return(build.IntLit(0)),
+			))},
+			&Result{Stdout: "-7 hi    03|4  |ffffffff A %\n"},
+		},
+		{
+			"c division",
+			[]*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(
+				build.Call("printf", build.Str("%d %d\n"), build.Bin(build.Div, build.IntLit(-7), build.IntLit(2)), build.Bin(build.Mod, build.IntLit(-7), build.IntLit(2))),
+				build.Return(build.IntLit(0)),
+			))},
+			&Result{Stdout: "-3 -1\n"},
+		},
+		{
+			"bitwise",
+			[]*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(
+				build.Call("printf", build.Str("%d %d %d\n"),
+					build.Bin(build.Shr, build.IntLit(-16), build.IntLit(2)),
+					build.Bin(build.BitXor, build.Un(build.BitNot, build.IntLit(5)), build.IntLit(3)),
+					build.Un(build.Neg, build.Bin(build.BitAnd, build.IntLit(-6), build.IntLit(7))),
+				),
+				build.Return(build.IntLit(0)),
+			))},
+			&Result{Stdout: "-4 -7 -2\n"},
+		},
+		{
+			"labeled continue",
+			[]*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("n", build.Int, build.IntLit(0)),
+				build.Labeled("outer", build.For(
+					build.Define("i", build.Int, build.IntLit(0)),
+					build.Bin(build.Lt, build.Id("i"), build.IntLit(3)),
+					build.Assign(build.Id("i"), build.Bin(build.Add, build.Id("i"), build.IntLit(1))),
+					build.Block(
+						build.While(build.IntLit(1), build.Block(
+							build.Assign(build.Id("n"), build.Bin(build.Add, build.Id("n"), build.IntLit(1))),
+							build.If(build.Bin(build.Eq, build.Id("i"), build.IntLit(1)), build.Block(build.BreakTo("outer"))),
+							build.ContinueTo("outer"),
+						)),
+					),
+				)),
+				build.Return(build.Id("n")),
+			))},
+			&Result{ExitCode: 2},
+		},
+		{
+			"loops and calls",
+			[]*interlang.Node{
+				build.Define("total", build.Int, build.IntLit(0)),
+				build.Func("count", build.Int, build.Params(), build.Block(
+					build.For(
+						build.Define("i", build.Int, build.IntLit(0)),
+						build.Bin(build.Lt, build.Id("i"), build.IntLit(3)),
+						build.Assign(build.Id("i"), build.Bin(build.Add, build.Id("i"), build.IntLit(1))),
+						build.Block(
+							build.Assign(build.Id("total"), build.Bin(build.Add, build.Id("total"), build.Id("i"))),
+						),
+					),
+					build.Return(build.Id("total")),
+				)),
+				build.Func("main", build.Int, build.Params(), build.Block(
+					build.Define("n", build.Int, build.IntLit(0)),
+					build.While(build.Bin(build.Lt, build.Id("n"), build.IntLit(2)), build.Block(
+						build.Call("count"),
+						build.Assign(build.Id("n"), build.Bin(build.Add, build.Id("n"), build.IntLit(1))),
+					)),
+					build.IfElse(build.Bin(build.Eq, build.Id("total"), build.IntLit(6)),
+						build.Block(build.Call("printf", build.Str("ok\n"))),
+						build.Block(build.Call("printf", build.Str("ng\n"))),
+					),
+					build.Return(build.Id("total")),
+				)),
+			},
+			&Result{Stdout: "ok\n", ExitCode: 6},
+		},
+		{
+			"params",
+			[]*interlang.Node{
+				build.Func("sub", build.Int, build.Params(build.Param("a", build.Int), build.ParamDefault("b", build.Int, build.IntLit(1))), build.Block(
+					build.Return(build.Bin(build.Sub, build.Id("a"), build.Id("b"))),
+				)),
+				build.Func("main", build.Int, build.Params(), build.Block(
+					build.Return(build.Bin(build.Add, build.Call("sub", build.IntLit(10), build.IntLit(3)), build.Call("sub", build.IntLit(5)))),
+				)),
+			},
+			&Result{ExitCode: 11},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
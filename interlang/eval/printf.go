@@ -0,0 +1,73 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sprintf Cのprintfの書式で文字列を組み立てる
+// 書式指定はGoのfmtとほぼ同じなので、長さ修飾子を落とし変換指定子だけ読み替える
+func sprintf(format string, args []value) (string, error) {
+	var sb strings.Builder
+	runes := []rune(format)
+	argi := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			sb.WriteRune(runes[i])
+			continue
+		}
+		i++
+		if i >= len(runes) {
+			return "", fmt.Errorf("incomplete format: %q", format)
+		}
+		if runes[i] == '%' {
+			sb.WriteRune('%')
+			continue
+		}
+
+		spec := "%"
+		// flags
+		for i < len(runes) && strings.ContainsRune("-+ #0", runes[i]) {
+			spec += string(runes[i])
+			i++
+		}
+		// width, precision
+		for i < len(runes) && strings.ContainsRune("0123456789.", runes[i]) {
+			spec += string(runes[i])
+			i++
+		}
+		// length modifiers
+		for i < len(runes) && strings.ContainsRune("hlLqjzt", runes[i]) {
+			i++
+		}
+		if i >= len(runes) {
+			return "", fmt.Errorf("incomplete format: %q", format)
+		}
+
+		if argi >= len(args) {
+			return "", fmt.Errorf("too few arguments for format: %q", format)
+		}
+		arg := args[argi]
+		argi++
+
+		conv := runes[i]
+		switch conv {
+		case 'd', 'i':
+			sb.WriteString(fmt.Sprintf(spec+"d", arg.i))
+		case 'u':
+			sb.WriteString(fmt.Sprintf(spec+"d", uint32(arg.i)))
+		case 'x', 'X', 'o':
+			sb.WriteString(fmt.Sprintf(spec+string(conv), uint32(arg.i)))
+		case 'c':
+			sb.WriteString(fmt.Sprintf(spec+"c", rune(uint8(arg.i))))
+		case 's':
+			if arg.kind != stringValue {
+				return "", fmt.Errorf("%%s requires a string argument")
+			}
+			sb.WriteString(fmt.Sprintf(spec+"s", arg.s))
+		default:
+			return "", fmt.Errorf("unsupported conversion: %%%c", conv)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,380 @@
+package fold
+
+import (
+	"cape/interlang"
+	"math"
+)
+
+// Options 畳み込みで行う変形の選択
+// どれも無効にすると入力をそのまま返す
+type Options struct {
+	// Constants 定数同士の演算を計算済みのリテラルに置き換える
+	Constants bool
+	// Identities x + 0、x * 1 のような恒等式を簡約する
+	Identities bool
+	// Branches 条件が定数のIfElse、Whileの到達しない枝を取り除く
+	Branches bool
+}
+
+// All 全ての変形を有効にしたOptions
+var All = Options{Constants: true, Identities: true, Branches: true}
+
+// Fold 中間言語のプログラムに定数畳み込みと簡約を施す
+// 整数はCのintと同じく32bitで、除算はゼロ方向に切り捨て、オーバーフローは折り返す
+// 入力の木は書き換えず、変形したノードだけ新しく作る
+func Fold(nodes []*interlang.Node, opts Options) []*interlang.Node {
+	f := &folder{opts}
+	var folded []*interlang.Node
+	for _, node := range nodes {
+		folded = append(folded, f.toplevel(node))
+	}
+	return folded
+}
+
+type folder struct {
+	opts Options
+}
+
+func (f *folder) toplevel(node *interlang.Node) *interlang.Node {
+	switch field := node.GetField().(type) {
+	case *interlang.FunctionDefineField:
+		fn := *field
+		fn.Block = f.stmt(field.Block)
+		return interlang.NewNode(node.GetKind(), &fn).WithSpan(node.GetSpan())
+	case *interlang.VariableDefineField:
+		def := *field
+		def.Value = f.expr(field.Value)
+		return interlang.NewNode(node.GetKind(), &def).WithSpan(node.GetSpan())
+	default:
+		return node
+	}
+}
+
+// stmts 文の並びを畳み込む
+// 取り除かれた文は詰め、枝を選んだ後のif文のブロックは親に展開する
+func (f *folder) stmts(nodes []*interlang.Node) []*interlang.Node {
+	var stmts []*interlang.Node
+	for _, node := range nodes {
+		stmt := f.stmt(node)
+		if stmt == nil {
+			continue
+		}
+		if node.GetKind() == interlang.IfElse && stmt.GetKind() == interlang.Block && !declares(stmt) {
+			stmts = append(stmts, stmt.GetField().(*interlang.BlockField).Stmts...)
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// stmt 文を畳み込む
+// 文が丸ごと消える場合はnilを返す
+func (f *folder) stmt(node *interlang.Node) *interlang.Node {
+	if node == nil {
+		return nil
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.BlockField:
+		return interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: f.stmts(field.Stmts)}).WithSpan(node.GetSpan())
+	case *interlang.VariableDefineField:
+		def := *field
+		def.Value = f.expr(field.Value)
+		return interlang.NewNode(node.GetKind(), &def).WithSpan(node.GetSpan())
+	case *interlang.ReturnField:
+		ret := *field
+		ret.Value = f.expr(field.Value)
+		return interlang.NewNode(node.GetKind(), &ret).WithSpan(node.GetSpan())
+	case *interlang.IfElseField:
+		cond := f.expr(field.Cond)
+		if v, ok := intValue(cond); ok && f.opts.Branches {
+			if v != 0 {
+				return f.stmt(field.IfBlock)
+			}
+			// else節がなければif文ごと消える
+			return f.stmt(field.ElseBlock)
+		}
+		return interlang.NewNode(interlang.IfElse, &interlang.IfElseField{
+			Cond:      cond,
+			IfBlock:   f.stmt(field.IfBlock),
+			ElseBlock: f.stmt(field.ElseBlock),
+		}).WithSpan(node.GetSpan())
+	case *interlang.WhileField:
+		cond := f.expr(field.Cond)
+		// 一度も回らないループ
+		if v, ok := intValue(cond); ok && v == 0 && f.opts.Branches {
+			return nil
+		}
+		loop := *field
+		loop.Cond = cond
+		loop.Block = f.stmt(field.Block)
+		return interlang.NewNode(interlang.While, &loop).WithSpan(node.GetSpan())
+	case *interlang.ForField:
+		loop := *field
+		if field.Init != nil && field.Init.GetKind() == interlang.VariableDefine {
+			loop.Init = f.stmt(field.Init)
+		} else {
+			loop.Init = f.expr(field.Init)
+		}
+		loop.Cond = f.expr(field.Cond)
+		loop.Loop = f.expr(field.Loop)
+		loop.Block = f.stmt(field.Block)
+		return interlang.NewNode(interlang.For, &loop).WithSpan(node.GetSpan())
+	default:
+		return f.expr(node)
+	}
+}
+
+func (f *folder) expr(node *interlang.Node) *interlang.Node {
+	if node == nil {
+		return nil
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.AssignField:
+		return interlang.NewNode(interlang.Assign, &interlang.AssignField{
+			To:    field.To,
+			Value: f.expr(field.Value),
+		}).WithSpan(node.GetSpan())
+	case *interlang.BinaryField:
+		return f.binary(node, field)
+	case *interlang.NotField:
+		value := f.expr(field.Value)
+		if v, ok := intValue(value); ok && f.opts.Constants {
+			return intLiteral(node, boolToInt(v == 0))
+		}
+		return interlang.NewNode(interlang.Not, &interlang.NotField{Value: value}).WithSpan(node.GetSpan())
+	case *interlang.UnaryField:
+		value := f.expr(field.Value)
+		if v, ok := intValue(value); ok && f.opts.Constants {
+			if folded, ok := evalUnary(field.Operation, v); ok {
+				return intLiteral(node, folded)
+			}
+		}
+		// +x
+		if field.Operation == interlang.Plus && f.opts.Identities {
+			return value
+		}
+		unary := *field
+		unary.Value = value
+		return interlang.NewNode(interlang.Unary, &unary).WithSpan(node.GetSpan())
+	case *interlang.CallField:
+		call := *field
+		call.Args = f.expr(field.Args)
+		return interlang.NewNode(interlang.Call, &call).WithSpan(node.GetSpan())
+	case *interlang.MultipleField:
+		multi := *field
+		multi.Values = nil
+		for _, value := range field.Values {
+			multi.Values = append(multi.Values, f.expr(value))
+		}
+		return interlang.NewNode(interlang.Multiple, &multi).WithSpan(node.GetSpan())
+	default:
+		return node
+	}
+}
+
+func (f *folder) binary(node *interlang.Node, field *interlang.BinaryField) *interlang.Node {
+	lhs := f.expr(field.LHS)
+	rhs := f.expr(field.RHS)
+
+	l, lok := intValue(lhs)
+	r, rok := intValue(rhs)
+	if lok && rok && f.opts.Constants {
+		if v, ok := evalBinary(field.Operation, l, r); ok {
+			return intLiteral(node, v)
+		}
+	}
+
+	if f.opts.Identities {
+		if simplified := simplify(node, field.Operation, lhs, rhs, lok, l, rok, r); simplified != nil {
+			return simplified
+		}
+	}
+
+	bin := *field
+	bin.LHS = lhs
+	bin.RHS = rhs
+	return interlang.NewNode(interlang.Binary, &bin).WithSpan(node.GetSpan())
+}
+
+// evalBinary 整数の二項演算をCのintとして計算する
+// ゼロ除算のように結果が未定義なものは畳み込まない
+func evalBinary(op interlang.Operation, l, r int32) (int32, bool) {
+	switch op {
+	case interlang.Add:
+		return l + r, true
+	case interlang.Sub:
+		return l - r, true
+	case interlang.Mul:
+		return l * r, true
+	case interlang.Div:
+		if r == 0 || (l == math.MinInt32 && r == -1) {
+			return 0, false
+		}
+		return l / r, true
+	case interlang.Mod:
+		if r == 0 || (l == math.MinInt32 && r == -1) {
+			return 0, false
+		}
+		return l % r, true
+	case interlang.And:
+		return boolToInt(l != 0 && r != 0), true
+	case interlang.Or:
+		return boolToInt(l != 0 || r != 0), true
+	case interlang.Eq:
+		return boolToInt(l == r), true
+	case interlang.Ne:
+		return boolToInt(l != r), true
+	case interlang.Lt:
+		return boolToInt(l < r), true
+	case interlang.Le:
+		return boolToInt(l <= r), true
+	case interlang.Gt:
+		return boolToInt(l > r), true
+	case interlang.Ge:
+		return boolToInt(l >= r), true
+	case interlang.BitAnd:
+		return l & r, true
+	case interlang.BitOr:
+		return l | r, true
+	case interlang.BitXor:
+		return l ^ r, true
+	case interlang.Shl:
+		if r < 0 || r >= 32 {
+			return 0, false
+		}
+		return int32(uint32(l) << r), true
+	case interlang.Shr:
+		if r < 0 || r >= 32 {
+			return 0, false
+		}
+		return l >> r, true
+	default:
+		return 0, false
+	}
+}
+
+// evalUnary 整数の単項演算をCのintとして計算する
+func evalUnary(op interlang.Operation, v int32) (int32, bool) {
+	switch op {
+	case interlang.Neg:
+		return -v, true
+	case interlang.Plus:
+		return v, true
+	case interlang.BitNot:
+		return ^v, true
+	default:
+		return 0, false
+	}
+}
+
+// simplify 片方が定数の恒等式を簡約する
+// 簡約できなければnilを返す
+func simplify(node *interlang.Node, op interlang.Operation, lhs, rhs *interlang.Node, lok bool, l int32, rok bool, r int32) *interlang.Node {
+	switch op {
+	case interlang.And:
+		// 0 && x は右辺を評価しない
+		if lok && l == 0 {
+			return intLiteral(node, 0)
+		}
+	case interlang.Or:
+		// 1 || x は右辺を評価しない
+		if lok && l != 0 {
+			return intLiteral(node, 1)
+		}
+	case interlang.Add:
+		// 0 + x
+		if lok && l == 0 {
+			return rhs
+		}
+		// x + 0
+		if rok && r == 0 {
+			return lhs
+		}
+	case interlang.Sub:
+		// x - 0
+		if rok && r == 0 {
+			return lhs
+		}
+	case interlang.Mul:
+		// 1 * x
+		if lok && l == 1 {
+			return rhs
+		}
+		// x * 1
+		if rok && r == 1 {
+			return lhs
+		}
+		// 0 * x、x * 0 は副作用がない時だけ0にできる
+		if lok && l == 0 && pure(rhs) {
+			return lhs
+		}
+		if rok && r == 0 && pure(lhs) {
+			return rhs
+		}
+	case interlang.Div:
+		// x / 1
+		if rok && r == 1 {
+			return lhs
+		}
+	}
+	return nil
+}
+
+// pure 式に副作用がないか
+func pure(node *interlang.Node) bool {
+	if node == nil {
+		return true
+	}
+	switch field := node.GetField().(type) {
+	case *interlang.LiteralField, *interlang.IdentField:
+		return true
+	case *interlang.NotField:
+		return pure(field.Value)
+	case *interlang.UnaryField:
+		return pure(field.Value)
+	case *interlang.BinaryField:
+		// ゼロ除算やシフト幅が範囲外になる可能性がある
+		switch field.Operation {
+		case interlang.Div, interlang.Mod, interlang.Shl, interlang.Shr:
+			return false
+		}
+		return pure(field.LHS) && pure(field.RHS)
+	default:
+		return false
+	}
+}
+
+// declares ブロックが直下で変数を宣言しているか
+// 宣言を含むブロックは展開するとスコープが変わってしまう
+func declares(block *interlang.Node) bool {
+	for _, stmt := range block.GetField().(*interlang.BlockField).Stmts {
+		switch stmt.GetKind() {
+		case interlang.VariableDeclare, interlang.VariableDefine:
+			return true
+		}
+	}
+	return false
+}
+
+func intValue(node *interlang.Node) (int32, bool) {
+	if node == nil || node.GetKind() != interlang.Literal {
+		return 0, false
+	}
+	lit := node.GetField().(*interlang.LiteralField)
+	if lit.GetTType() != interlang.Integer {
+		return 0, false
+	}
+	return int32(lit.I), true
+}
+
+func intLiteral(node *interlang.Node, v int32) *interlang.Node {
+	return interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: int(v)}).WithSpan(node.GetSpan())
+}
+
+func boolToInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
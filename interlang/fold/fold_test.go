@@ -0,0 +1,105 @@
+package fold
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"math"
+	"testing"
+)
+
+func main(stmts ...*interlang.Node) []*interlang.Node {
+	return []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(stmts...))}
+}
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		in     []*interlang.Node
+		expect []*interlang.Node
+	}{
+		{
+			"constants",
+			All,
+			main(build.Return(build.Bin(build.Add, build.Bin(build.Mul, build.IntLit(10), build.IntLit(2)), build.IntLit(1)))),
+			main(build.Return(build.IntLit(21))),
+		},
+		{
+			"truncating division",
+			All,
+			main(build.Return(build.Bin(build.Add, build.Bin(build.Div, build.IntLit(-7), build.IntLit(2)), build.Bin(build.Mod, build.IntLit(-7), build.IntLit(2))))),
+			main(build.Return(build.IntLit(-4))),
+		},
+		{
+			"overflow wraps",
+			All,
+			main(build.Return(build.Bin(build.Add, build.IntLit(math.MaxInt32), build.IntLit(1)))),
+			main(build.Return(build.IntLit(math.MinInt32))),
+		},
+		{
+			"division by zero is kept",
+			All,
+			main(build.Return(build.Bin(build.Div, build.IntLit(1), build.IntLit(0)))),
+			main(build.Return(build.Bin(build.Div, build.IntLit(1), build.IntLit(0)))),
+		},
+		{
+			"bitwise on negatives",
+			All,
+			main(build.Return(build.Bin(build.BitOr, build.Bin(build.Shr, build.IntLit(-16), build.IntLit(2)), build.Un(build.BitNot, build.IntLit(-1))))),
+			main(build.Return(build.IntLit(-4))),
+		},
+		{
+			"shift wraps",
+			All,
+			main(build.Return(build.Bin(build.Shl, build.IntLit(1), build.IntLit(31)))),
+			main(build.Return(build.IntLit(math.MinInt32))),
+		},
+		{
+			"identities",
+			All,
+			main(build.Return(build.Bin(build.Sub, build.Bin(build.Mul, build.Var("x", build.Int), build.IntLit(1)), build.IntLit(0)))),
+			main(build.Return(build.Var("x", build.Int))),
+		},
+		{
+			"dead if",
+			All,
+			main(
+				build.If(build.Bin(build.Eq, build.IntLit(1), build.IntLit(2)), build.Block(build.Return(build.IntLit(1)))),
+				build.Return(build.IntLit(0)),
+			),
+			main(build.Return(build.IntLit(0))),
+		},
+		{
+			"taken else",
+			All,
+			main(
+				build.IfElse(build.Not(build.IntLit(1)), build.Block(build.Return(build.IntLit(1))), build.Block(build.Return(build.IntLit(2)))),
+			),
+			main(build.Return(build.IntLit(2))),
+		},
+		{
+			"dead while",
+			All,
+			main(
+				build.While(build.IntLit(0), build.Block(build.Return(build.IntLit(1)))),
+				build.Return(build.IntLit(0)),
+			),
+			main(build.Return(build.IntLit(0))),
+		},
+		{
+			"disabled",
+			Options{},
+			main(build.Return(build.Bin(build.Add, build.Var("x", build.Int), build.Bin(build.Add, build.IntLit(1), build.IntLit(2))))),
+			main(build.Return(build.Bin(build.Add, build.Var("x", build.Int), build.Bin(build.Add, build.IntLit(1), build.IntLit(2))))),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fold(tt.in, tt.opts)
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package interlang
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSONVersion 中間言語をjsonに書き出す際のスキーマのバージョン
+// フォーマットに互換性のない変更を加えたら上げること
+const JSONVersion = 1
+
+type jsonDocument struct {
+	Version int     `json:"version"`
+	Nodes   []*Node `json:"nodes"`
+}
+
+type jsonProgramDocument struct {
+	Version int      `json:"version"`
+	Program *Program `json:"program"`
+}
+
+type jsonNode struct {
+	Kind  string          `json:"kind"`
+	Field json.RawMessage `json:"field"`
+	Span  *Span           `json:"span,omitempty"`
+}
+
+// MarshalNodes 中間言語のプログラムをバージョン付きのjsonに変換する
+func MarshalNodes(nodes []*Node) ([]byte, error) {
+	return json.MarshalIndent(jsonDocument{Version: JSONVersion, Nodes: nodes}, "", "  ")
+}
+
+// UnmarshalNodes MarshalNodesで書き出したjsonを中間言語のプログラムに戻す
+func UnmarshalNodes(data []byte) ([]*Node, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported interlang json version: %d (want %d)", doc.Version, JSONVersion)
+	}
+	return doc.Nodes, nil
+}
+
+// MarshalProgram Programをバージョン付きのjsonに変換する
+func MarshalProgram(p *Program) ([]byte, error) {
+	return json.MarshalIndent(jsonProgramDocument{Version: JSONVersion, Program: p}, "", "  ")
+}
+
+// UnmarshalProgram MarshalProgramで書き出したjsonをProgramに戻す
+// MarshalNodesで書き出したノードの並びも受け付ける
+func UnmarshalProgram(data []byte) (*Program, error) {
+	var doc struct {
+		jsonProgramDocument
+		Nodes []*Node `json:"nodes"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported interlang json version: %d (want %d)", doc.Version, JSONVersion)
+	}
+	if doc.Program != nil {
+		return doc.Program, nil
+	}
+	return NewProgram(doc.Nodes)
+}
+
+func (n *Node) MarshalJSON() ([]byte, error) {
+	field, err := json.Marshal(n.Field)
+	if err != nil {
+		return nil, err
+	}
+	jn := jsonNode{Kind: n.NodeKind.String(), Field: field}
+	if n.Span.IsValid() {
+		span := n.Span
+		jn.Span = &span
+	}
+	return json.Marshal(jn)
+}
+
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var jn jsonNode
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	kind, ok := nodeKindFromString(jn.Kind)
+	if !ok {
+		return fmt.Errorf("unknown node kind: %q", jn.Kind)
+	}
+	field, err := unmarshalField(kind, jn.Field)
+	if err != nil {
+		return fmt.Errorf("%v: %w", kind, err)
+	}
+	n.NodeKind = kind
+	n.Field = field
+	n.Span = Span{}
+	if jn.Span != nil {
+		n.Span = *jn.Span
+	}
+	return nil
+}
+
+func unmarshalField(kind NodeKind, data json.RawMessage) (Field, error) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	field := newField(kind)
+	if field == nil {
+		return nil, fmt.Errorf("no field for node kind")
+	}
+	if u, ok := field.(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(data); err != nil {
+			return nil, err
+		}
+		return field, nil
+	}
+
+	// TTypeはinterfaceなのでencoding/jsonに任せると戻せない
+	// 先に取り出して自前で復元する
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	rawTType, hasTType := members["TType"]
+	delete(members, "TType")
+	rest, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rest, field); err != nil {
+		return nil, err
+	}
+
+	if hasTType {
+		tt, err := UnmarshalTType(rawTType)
+		if err != nil {
+			return nil, err
+		}
+		v := reflect.ValueOf(field).Elem().FieldByName("TType")
+		if !v.IsValid() {
+			return nil, fmt.Errorf("field %T has no type", field)
+		}
+		if tt != nil {
+			v.Set(reflect.ValueOf(tt))
+		}
+	}
+	return field, nil
+}
+
+type jsonBinaryField struct {
+	TType     json.RawMessage
+	Operation string
+	LHS       *Node
+	RHS       *Node
+}
+
+// Operationを埋め込んでいるので、演算子は名前で書き出すよう明示的に変換する
+func (f *BinaryField) MarshalJSON() ([]byte, error) {
+	tt, err := MarshalTType(f.TType)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonBinaryField{TType: tt, Operation: marshalOperation(f.Operation), LHS: f.LHS, RHS: f.RHS})
+}
+
+func (f *BinaryField) UnmarshalJSON(data []byte) error {
+	var jf jsonBinaryField
+	if err := json.Unmarshal(data, &jf); err != nil {
+		return err
+	}
+	tt, err := UnmarshalTType(jf.TType)
+	if err != nil {
+		return err
+	}
+	op, err := unmarshalOperation(jf.Operation)
+	if err != nil {
+		return err
+	}
+	*f = BinaryField{TType: tt, Operation: op, LHS: jf.LHS, RHS: jf.RHS}
+	return nil
+}
+
+type jsonUnaryField struct {
+	TType     json.RawMessage
+	Operation string
+	Value     *Node
+}
+
+func (f *UnaryField) MarshalJSON() ([]byte, error) {
+	tt, err := MarshalTType(f.TType)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonUnaryField{TType: tt, Operation: marshalOperation(f.Operation), Value: f.Value})
+}
+
+func (f *UnaryField) UnmarshalJSON(data []byte) error {
+	var jf jsonUnaryField
+	if err := json.Unmarshal(data, &jf); err != nil {
+		return err
+	}
+	tt, err := UnmarshalTType(jf.TType)
+	if err != nil {
+		return err
+	}
+	op, err := unmarshalOperation(jf.Operation)
+	if err != nil {
+		return err
+	}
+	*f = UnaryField{TType: tt, Operation: op, Value: jf.Value}
+	return nil
+}
+
+func marshalOperation(op Operation) string {
+	if op == 0 {
+		return ""
+	}
+	return op.String()
+}
+
+func unmarshalOperation(s string) (Operation, error) {
+	if s == "" {
+		return 0, nil
+	}
+	op, ok := operationFromString(s)
+	if !ok {
+		return 0, fmt.Errorf("unknown operation: %q", s)
+	}
+	return op, nil
+}
+
+// MarshalTType 型をjsonに変換する
+// プリミティブは名前の文字列、タプルは配列、
+// ポインタ、配列、構造体は{"Pointer": 要素}、{"Array": 要素, "Len": 長さ}、{"Struct": 名前}になる
+func MarshalTType(tt TType) ([]byte, error) {
+	return json.Marshal(tt)
+}
+
+// UnmarshalTType MarshalTTypeで書き出したjsonを型に戻す
+func UnmarshalTType(data []byte) (TType, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	switch data[0] {
+	case '"':
+		var p TPrimitive
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case '[':
+		var tuple TTuple
+		if err := json.Unmarshal(data, &tuple); err != nil {
+			return nil, err
+		}
+		return tuple, nil
+	case '{':
+		return unmarshalCompositeTType(data)
+	default:
+		return nil, fmt.Errorf("unexpected type json: %s", string(data))
+	}
+}
+
+func unmarshalCompositeTType(data []byte) (TType, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	switch {
+	case members["Pointer"] != nil:
+		elem, err := UnmarshalTType(members["Pointer"])
+		if err != nil {
+			return nil, err
+		}
+		return TPointer{Elem: elem}, nil
+	case members["Array"] != nil:
+		elem, err := UnmarshalTType(members["Array"])
+		if err != nil {
+			return nil, err
+		}
+		var n int
+		if members["Len"] != nil {
+			if err := json.Unmarshal(members["Len"], &n); err != nil {
+				return nil, err
+			}
+		}
+		return TArray{Elem: elem, Len: n}, nil
+	case members["Struct"] != nil:
+		var name string
+		if err := json.Unmarshal(members["Struct"], &name); err != nil {
+			return nil, err
+		}
+		return TStruct{Name: name}, nil
+	default:
+		return nil, fmt.Errorf("unexpected type json: %s", string(data))
+	}
+}
+
+func (tt TPointer) MarshalJSON() ([]byte, error) {
+	elem, err := MarshalTType(tt.Elem)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]json.RawMessage{"Pointer": elem})
+}
+
+func (tt TArray) MarshalJSON() ([]byte, error) {
+	elem, err := MarshalTType(tt.Elem)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Array json.RawMessage
+		Len   int
+	}{elem, tt.Len})
+}
+
+func (tt TStruct) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"Struct": tt.Name})
+}
+
+func (tt *TTuple) UnmarshalJSON(data []byte) error {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	tuple := TTuple{}
+	for _, elem := range elems {
+		t, err := UnmarshalTType(elem)
+		if err != nil {
+			return err
+		}
+		tuple = append(tuple, t)
+	}
+	*tt = tuple
+	return nil
+}
@@ -0,0 +1,154 @@
+package interlang
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func TestMarshalNodes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []*Node
+	}{
+		{
+			"return",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  Integer,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(Return, &ReturnField{Value: NewNode(Literal, &LiteralField{TType: Integer, I: 32})}),
+					}}),
+				}),
+			},
+		},
+		{
+			"printf",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType: Integer,
+					Ident: NewNode(Ident, &IdentField{S: "main"}),
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(VariableDefine, &VariableDefineField{
+							TType: Integer,
+							Ident: NewNode(Ident, &IdentField{TType: Integer, S: "x"}),
+							Value: NewNode(Binary, &BinaryField{
+								TType:     Integer,
+								Operation: Add,
+								LHS:       NewNode(Literal, &LiteralField{TType: Integer, I: 1}),
+								RHS:       NewNode(Literal, &LiteralField{TType: Integer, I: 2}),
+							}),
+						}),
+						NewNode(IfElse, &IfElseField{
+							Cond:    NewNode(Not, &NotField{Value: NewNode(Ident, &IdentField{TType: Integer, S: "x"})}),
+							IfBlock: NewNode(Block, &BlockField{}),
+						}),
+						NewNode(Call, &CallField{
+							TType: Integer,
+							Ident: NewNode(Ident, &IdentField{S: "printf"}),
+							Args: NewNode(Multiple, &MultipleField{
+								TType: TTuple{String, Integer},
+								Values: []*Node{
+									NewNode(Literal, &LiteralField{TType: String, S: "%d\n"}),
+									NewNode(Ident, &IdentField{TType: Integer, S: "x"}).WithSpan(Span{Line: 4, Col: 20, EndLine: 4, EndCol: 21}),
+								},
+							}),
+						}).WithSpan(Span{File: "main.c", Line: 4, Col: 5, EndLine: 4, EndCol: 22}),
+					}}),
+				}),
+			},
+		},
+		{
+			"composite types",
+			[]*Node{
+				NewNode(FunctionDeclare, &FunctionDeclareField{
+					TType: TPointer{Elem: TStruct{Name: "point"}},
+					Ident: NewNode(Ident, &IdentField{S: "f"}),
+					Params: NewNode(Multiple, &MultipleField{Values: []*Node{
+						NewNode(Param, &ParamField{TType: TArray{Elem: Integer, Len: 3}, Ident: NewNode(Ident, &IdentField{S: "xs"})}),
+						NewNode(Param, &ParamField{TType: TPointer{Elem: TPointer{Elem: Integer}}, Ident: NewNode(Ident, &IdentField{S: "p"})}),
+					}}),
+				}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalNodes(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := UnmarshalNodes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.in, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalNodesVersion(t *testing.T) {
+	_, err := UnmarshalNodes([]byte(`{"version": 999, "nodes": []}`))
+	if err == nil || !strings.Contains(err.Error(), "version") {
+		t.Fatalf("expected version error, got %v", err)
+	}
+}
+
+func TestMarshalProgram(t *testing.T) {
+	in := &Program{
+		Source:  SourceFile{Name: "main.c"},
+		Imports: []*Import{{Name: "stdio.h"}},
+		Types: []*Node{
+			NewNode(TypeDefine, &TypeDefineField{TType: Integer, Ident: NewNode(Ident, &IdentField{TType: Integer, S: "score"})}),
+		},
+		Globals: []*Node{
+			NewNode(VariableDeclare, &VariableDeclareField{TType: Integer, Ident: NewNode(Ident, &IdentField{TType: Integer, S: "count"})}),
+		},
+		Decls: []*Node{
+			NewNode(FunctionDeclare, &FunctionDeclareField{TType: Integer, Ident: NewNode(Ident, &IdentField{S: "main"})}),
+		},
+		Funcs: []*Node{
+			NewNode(FunctionDefine, &FunctionDefineField{
+				TType: Integer,
+				Ident: NewNode(Ident, &IdentField{S: "main"}),
+				Block: NewNode(Block, &BlockField{}),
+			}),
+		},
+	}
+	data, err := MarshalProgram(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalProgram(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(in, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+func TestUnmarshalProgramNodes(t *testing.T) {
+	data, err := MarshalNodes([]*Node{
+		NewNode(VariableDeclare, &VariableDeclareField{TType: Integer, Ident: NewNode(Ident, &IdentField{TType: Integer, S: "count"})}),
+		NewNode(FunctionDefine, &FunctionDefineField{
+			TType: Integer,
+			Ident: NewNode(Ident, &IdentField{S: "main"}),
+			Block: NewNode(Block, &BlockField{}),
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnmarshalProgram(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Globals) != 1 || len(got.Funcs) != 1 {
+		t.Fatalf("unexpected program: %+v", got)
+	}
+}
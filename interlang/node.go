@@ -1,32 +1,11 @@
 package interlang
 
-type NodeKind int
-
-const (
-	_ NodeKind = iota
-	VariableDeclare
-	FunctionDeclare
-	VariableDefine
-	FunctionDefine
-
-	Block
-	IfElse
-	While
-	For
-	Assign
-	Binary
-	Literal
-	Not
-	Multiple
-	Return
-	Call
-
-	Ident
-)
+//go:generate go run cape/cmd/nodegen -dialect interlang
 
 type Node struct {
 	NodeKind
 	Field
+	Span Span
 }
 
 func (n *Node) GetKind() NodeKind {
@@ -35,7 +14,16 @@ func (n *Node) GetKind() NodeKind {
 func (n *Node) GetField() Field {
 	return n.Field
 }
+func (n *Node) GetSpan() Span {
+	return n.Span
+}
+
+// WithSpan 位置を設定して自身を返す
+func (n *Node) WithSpan(span Span) *Node {
+	n.Span = span
+	return n
+}
 
 func NewNode(kind NodeKind, field Field) *Node {
-	return &Node{kind, field}
+	return &Node{NodeKind: kind, Field: field}
 }
@@ -0,0 +1,111 @@
+package interlang
+
+import "fmt"
+
+// SourceFile 翻訳元のファイルの情報
+type SourceFile struct {
+	Name string
+}
+
+// Import プログラムが必要とするライブラリ
+// Cのヘッダ名("stdio.h"など)で表す
+type Import struct {
+	Name string
+}
+
+// Program 翻訳単位全体
+// トップレベルの宣言を種類ごとに元の順番のまま持つ
+type Program struct {
+	Source  SourceFile
+	Imports []*Import
+	// TypeDefine
+	Types []*Node
+	// VariableDeclare、VariableDefine
+	Globals []*Node
+	// FunctionDeclare
+	Decls []*Node
+	// FunctionDefine
+	Funcs []*Node
+}
+
+// NewProgram トップレベルのノードの並びを種類ごとに振り分けてProgramにする
+func NewProgram(nodes []*Node) (*Program, error) {
+	p := &Program{}
+	for _, node := range nodes {
+		if err := p.Add(node); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Add トップレベルのノードを種類に応じた場所に加える
+func (p *Program) Add(node *Node) error {
+	switch node.GetKind() {
+	case TypeDefine:
+		p.Types = append(p.Types, node)
+	case VariableDeclare, VariableDefine:
+		p.Globals = append(p.Globals, node)
+	case FunctionDeclare:
+		p.Decls = append(p.Decls, node)
+	case FunctionDefine:
+		p.Funcs = append(p.Funcs, node)
+	default:
+		return fmt.Errorf("%v: %v is not allowed at toplevel", node.GetSpan(), node.GetKind())
+	}
+	return nil
+}
+
+// Nodes 型定義、大域変数、関数宣言、関数定義の順にトップレベルのノードを並べる
+func (p *Program) Nodes() []*Node {
+	var nodes []*Node
+	nodes = append(nodes, p.Types...)
+	nodes = append(nodes, p.Globals...)
+	nodes = append(nodes, p.Decls...)
+	nodes = append(nodes, p.Funcs...)
+	return nodes
+}
+
+// ImportNames 必要とするライブラリの名前
+func (p *Program) ImportNames() []string {
+	var names []string
+	for _, imp := range p.Imports {
+		names = append(names, imp.Name)
+	}
+	return names
+}
+
+// ValidateProgram Programの各部分に置かれたノードの種類と、ノードの木の構造を調べる
+func ValidateProgram(p *Program) []error {
+	v := &validator{}
+	for i, imp := range p.Imports {
+		if imp == nil || imp.Name == "" {
+			v.errorf(fmt.Sprintf("Imports[%d]", i), "empty import")
+		}
+	}
+	v.section("Types", p.Types, TypeDefine)
+	v.section("Globals", p.Globals, VariableDeclare, VariableDefine)
+	v.section("Decls", p.Decls, FunctionDeclare)
+	v.section("Funcs", p.Funcs, FunctionDefine)
+	return v.errs
+}
+
+func (v *validator) section(name string, nodes []*Node, kinds ...NodeKind) {
+	for i, node := range nodes {
+		path := fmt.Sprintf("%s[%d]", name, i)
+		if node != nil && !containsKind(kinds, node.GetKind()) {
+			v.errorf(path, "%v is not allowed in %s", node.GetKind(), name)
+			continue
+		}
+		v.toplevel(path, node)
+	}
+}
+
+func containsKind(kinds []NodeKind, kind NodeKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
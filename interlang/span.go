@@ -0,0 +1,27 @@
+package interlang
+
+import "fmt"
+
+// Span ノードに対応する元のCソース上の範囲
+// 行と列は1始まりで、Lineが0なら位置は不明
+type Span struct {
+	File    string
+	Line    int
+	Col     int
+	EndLine int
+	EndCol  int
+}
+
+func (s Span) IsValid() bool {
+	return s.Line > 0
+}
+
+func (s Span) String() string {
+	if !s.IsValid() {
+		return "-"
+	}
+	if s.File == "" {
+		return fmt.Sprintf("%d:%d", s.Line, s.Col)
+	}
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Col)
+}
@@ -1,5 +1,7 @@
 package interlang
 
+import "fmt"
+
 type TType interface {
 	IsEqual(tt2 TType) bool
 }
@@ -12,16 +14,114 @@ const (
 	Integer
 	String
 	Bool
+	Float
 )
 
-func (tt TPrimitive) IsEqual(tt2 TType) bool { // TODO
-	_ = tt2
-	return false
+var primitives = [...]string{
+	Null:    "Null",
+	Integer: "Integer",
+	String:  "String",
+	Bool:    "Bool",
+	Float:   "Float",
+}
+
+func (tt TPrimitive) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TPrimitive)
+	return ok && tt == other
+}
+
+func (tt TPrimitive) String() string {
+	if 0 < tt && int(tt) < len(primitives) {
+		return primitives[tt]
+	}
+	return fmt.Sprintf("TPrimitive(%d)", int(tt))
+}
+
+func (tt TPrimitive) MarshalText() ([]byte, error) {
+	if tt <= 0 || int(tt) >= len(primitives) {
+		return nil, fmt.Errorf("unknown primitive type: %d", int(tt))
+	}
+	return []byte(primitives[tt]), nil
+}
+
+func (tt *TPrimitive) UnmarshalText(text []byte) error {
+	for p, name := range primitives {
+		if name != "" && name == string(text) {
+			*tt = TPrimitive(p)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown primitive type: %q", string(text))
 }
 
 type TTuple []TType
 
-func (tt TTuple) IsEqual(tt2 TType) bool { // TODO
-	_ = tt2
-	return false
+func (tt TTuple) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TTuple)
+	if !ok || len(tt) != len(other) {
+		return false
+	}
+	for i := range tt {
+		if tt[i] == nil || other[i] == nil {
+			if tt[i] != other[i] {
+				return false
+			}
+			continue
+		}
+		if !tt[i].IsEqual(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TPointer ポインタ
+type TPointer struct {
+	Elem TType
+}
+
+func (tt TPointer) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TPointer)
+	return ok && equalElem(tt.Elem, other.Elem)
+}
+
+func (tt TPointer) String() string {
+	return fmt.Sprintf("Pointer(%v)", tt.Elem)
+}
+
+// TArray 配列
+// Lenが0なら長さを省略した配列
+type TArray struct {
+	Elem TType
+	Len  int
+}
+
+func (tt TArray) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TArray)
+	return ok && tt.Len == other.Len && equalElem(tt.Elem, other.Elem)
+}
+
+func (tt TArray) String() string {
+	return fmt.Sprintf("Array(%v, %d)", tt.Elem, tt.Len)
+}
+
+// TStruct 構造体
+type TStruct struct {
+	Name string
+}
+
+func (tt TStruct) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TStruct)
+	return ok && tt.Name == other.Name
+}
+
+func (tt TStruct) String() string {
+	return fmt.Sprintf("Struct(%s)", tt.Name)
+}
+
+func equalElem(a, b TType) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.IsEqual(b)
 }
@@ -0,0 +1,318 @@
+package interlang
+
+import "fmt"
+
+// ValidationError 中間言語の木の構造的な誤り
+// Pathはプログラムの先頭からそのノードまでの道筋
+// Spanは分かる範囲で最も近いノードの位置
+type ValidationError struct {
+	Path string
+	Span Span
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Span.IsValid() {
+		return fmt.Sprintf("%v: %s: %s", e.Span, e.Path, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+type validator struct {
+	errs []error
+	span Span
+	// 検査中の文を囲むループのラベル(ラベルなしは空文字)
+	loops []string
+}
+
+func (v *validator) errorf(path string, format string, a ...any) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Span: v.span, Msg: fmt.Sprintf(format, a...)})
+}
+
+// Validate バックエンドに渡す前に中間言語の木が正しく組み立てられているか調べる
+// NodeKindとFieldの組み合わせ、必須の子ノード、文と式の置き場所、Multipleの置き場所を検査する
+// 見つかった誤りを全て返し、問題がなければnilを返す
+func Validate(nodes []*Node) []error {
+	v := &validator{}
+	for i, node := range nodes {
+		v.toplevel(fmt.Sprintf("[%d]", i), node)
+	}
+	return v.errs
+}
+
+// node ノードがnilでなく、FieldがNodeKindと一致しているか調べる
+// 問題がなければ子の検査を続けて良いのでtrueを返す
+func (v *validator) node(path string, node *Node) bool {
+	if node == nil {
+		v.errorf(path, "missing node")
+		return false
+	}
+	if node.Span.IsValid() {
+		v.span = node.Span
+	}
+	if node.Field == nil {
+		v.errorf(path, "%v has no field", node.GetKind())
+		return false
+	}
+	if node.Field.GetKind() != node.GetKind() {
+		v.errorf(path, "%v node has %T", node.GetKind(), node.Field)
+		return false
+	}
+	return true
+}
+
+func (v *validator) toplevel(path string, node *Node) {
+	defer func(span Span) { v.span = span }(v.span)
+	if !v.node(path, node) {
+		return
+	}
+	switch node.GetKind() {
+	case VariableDeclare, FunctionDeclare, VariableDefine, FunctionDefine, TypeDefine:
+		v.declare(path, node)
+	default:
+		v.errorf(path, "%v is not allowed at toplevel", node.GetKind())
+	}
+}
+
+func (v *validator) declare(path string, node *Node) {
+	path = fmt.Sprintf("%s.%v", path, node.GetKind())
+	switch field := node.GetField().(type) {
+	case *VariableDeclareField:
+		v.ident(path+".Ident", field.Ident)
+	case *FunctionDeclareField:
+		v.ident(path+".Ident", field.Ident)
+		v.params(path+".Params", field.Params)
+	case *VariableDefineField:
+		v.ident(path+".Ident", field.Ident)
+		v.expr(path+".Value", field.Value)
+	case *FunctionDefineField:
+		v.ident(path+".Ident", field.Ident)
+		v.params(path+".Params", field.Params)
+		v.block(path+".Block", field.Block)
+	case *TypeDefineField:
+		v.ident(path+".Ident", field.Ident)
+		if field.TType == nil {
+			v.errorf(path, "missing type")
+		}
+	}
+}
+
+func (v *validator) ident(path string, node *Node) {
+	if !v.node(path, node) {
+		return
+	}
+	if node.GetKind() != Ident {
+		v.errorf(path, "expected Ident, found %v", node.GetKind())
+		return
+	}
+	if node.GetField().(*IdentField).S == "" {
+		v.errorf(path, "empty identifier")
+	}
+}
+
+func (v *validator) params(path string, node *Node) {
+	// 引数なし
+	if node == nil {
+		return
+	}
+	if !v.node(path, node) {
+		return
+	}
+	if node.GetKind() != Multiple {
+		v.errorf(path, "expected Multiple, found %v", node.GetKind())
+		return
+	}
+	hasDefault := false
+	for i, param := range node.GetField().(*MultipleField).Values {
+		v.param(fmt.Sprintf("%s.Multiple.Values[%d]", path, i), param, &hasDefault)
+	}
+}
+
+// param 既定値のない仮引数は既定値のある仮引数より前に置く
+func (v *validator) param(path string, node *Node, hasDefault *bool) {
+	defer func(span Span) { v.span = span }(v.span)
+	if !v.node(path, node) {
+		return
+	}
+	field, ok := node.GetField().(*ParamField)
+	if !ok {
+		v.errorf(path, "expected Param, found %v", node.GetKind())
+		return
+	}
+	path += ".Param"
+	v.ident(path+".Ident", field.Ident)
+	if field.Default == nil {
+		if *hasDefault {
+			v.errorf(path, "parameter without default follows parameter with default")
+		}
+		return
+	}
+	*hasDefault = true
+	v.expr(path+".Default", field.Default)
+}
+
+func (v *validator) block(path string, node *Node) {
+	if !v.node(path, node) {
+		return
+	}
+	if node.GetKind() != Block {
+		v.errorf(path, "expected Block, found %v", node.GetKind())
+		return
+	}
+	v.stmt(path, node)
+}
+
+func (v *validator) stmt(path string, node *Node) {
+	defer func(span Span) { v.span = span }(v.span)
+	if !v.node(path, node) {
+		return
+	}
+	switch field := node.GetField().(type) {
+	case *BlockField:
+		for i, stmt := range field.Stmts {
+			v.stmt(fmt.Sprintf("%s.Block.Stmts[%d]", path, i), stmt)
+		}
+	case *VariableDeclareField, *VariableDefineField:
+		v.declare(path, node)
+	case *FunctionDeclareField, *FunctionDefineField, *TypeDefineField:
+		v.errorf(path, "%v is only allowed at toplevel", node.GetKind())
+	case *IfElseField:
+		path += ".IfElse"
+		v.expr(path+".Cond", field.Cond)
+		v.block(path+".IfBlock", field.IfBlock)
+		if field.ElseBlock != nil {
+			// else if
+			if field.ElseBlock.GetKind() == IfElse {
+				v.stmt(path+".ElseBlock", field.ElseBlock)
+			} else {
+				v.block(path+".ElseBlock", field.ElseBlock)
+			}
+		}
+	case *WhileField:
+		path += ".While"
+		v.expr(path+".Cond", field.Cond)
+		v.loop(path, field.Label, field.Block)
+	case *ForField:
+		path += ".For"
+		if field.Init != nil {
+			switch field.Init.GetKind() {
+			case VariableDeclare, VariableDefine:
+				v.stmt(path+".Init", field.Init)
+			default:
+				v.expr(path+".Init", field.Init)
+			}
+		}
+		if field.Cond != nil {
+			v.expr(path+".Cond", field.Cond)
+		}
+		if field.Loop != nil {
+			v.expr(path+".Loop", field.Loop)
+		}
+		v.loop(path, field.Label, field.Block)
+	case *BreakField:
+		v.jump(path+".Break", field.Label)
+	case *ContinueField:
+		v.jump(path+".Continue", field.Label)
+	case *ReturnField:
+		path += ".Return"
+		// 値のないreturn;はどのバックエンドも値のないreturnにする
+		if field.Value == nil {
+			return
+		}
+		if field.Value.GetKind() == Multiple {
+			v.multiple(path+".Value", field.Value)
+		} else {
+			v.expr(path+".Value", field.Value)
+		}
+	default:
+		v.expr(path, node)
+	}
+}
+
+// loop ループの本体を検査する
+func (v *validator) loop(path string, label string, block *Node) {
+	if label != "" {
+		for _, outer := range v.loops {
+			if outer == label {
+				v.errorf(path, "duplicate loop label: %s", label)
+			}
+		}
+	}
+	v.loops = append(v.loops, label)
+	v.block(path+".Block", block)
+	v.loops = v.loops[:len(v.loops)-1]
+}
+
+// jump break、continueが対象のループの中にあるか調べる
+func (v *validator) jump(path string, label string) {
+	if len(v.loops) == 0 {
+		v.errorf(path, "outside of a loop")
+		return
+	}
+	if label == "" {
+		return
+	}
+	for _, outer := range v.loops {
+		if outer == label {
+			return
+		}
+	}
+	v.errorf(path, "unknown loop label: %s", label)
+}
+
+func (v *validator) multiple(path string, node *Node) {
+	if !v.node(path, node) {
+		return
+	}
+	if node.GetKind() != Multiple {
+		v.errorf(path, "expected Multiple, found %v", node.GetKind())
+		return
+	}
+	for i, value := range node.GetField().(*MultipleField).Values {
+		v.expr(fmt.Sprintf("%s.Multiple.Values[%d]", path, i), value)
+	}
+}
+
+func (v *validator) expr(path string, node *Node) {
+	defer func(span Span) { v.span = span }(v.span)
+	if !v.node(path, node) {
+		return
+	}
+	switch field := node.GetField().(type) {
+	case *AssignField:
+		path += ".Assign"
+		v.ident(path+".To", field.To)
+		v.expr(path+".Value", field.Value)
+	case *BinaryField:
+		path += ".Binary"
+		if field.Operation <= 0 || int(field.Operation) >= len(operations) || field.Operation.IsUnary() {
+			v.errorf(path, "invalid binary operation: %v", field.Operation)
+		}
+		v.expr(path+".LHS", field.LHS)
+		v.expr(path+".RHS", field.RHS)
+	case *UnaryField:
+		path += ".Unary"
+		if !field.Operation.IsUnary() {
+			v.errorf(path, "invalid unary operation: %v", field.Operation)
+		}
+		// アドレスを取れるのは変数だけ
+		if field.Operation == Addr {
+			v.ident(path+".Value", field.Value)
+		} else {
+			v.expr(path+".Value", field.Value)
+		}
+	case *NotField:
+		v.expr(path+".Not.Value", field.Value)
+	case *CallField:
+		path += ".Call"
+		v.ident(path+".Ident", field.Ident)
+		v.multiple(path+".Args", field.Args)
+	case *LiteralField:
+	case *IdentField:
+		v.ident(path, node)
+	case *MultipleField:
+		v.errorf(path, "Multiple is only allowed as call arguments or return values")
+	default:
+		v.errorf(path, "statement %v is used as an expression", node.GetKind())
+	}
+}
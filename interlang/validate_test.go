@@ -0,0 +1,156 @@
+package interlang
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	main := func(stmts ...*Node) []*Node {
+		return []*Node{
+			NewNode(FunctionDefine, &FunctionDefineField{
+				TType: Integer,
+				Ident: NewNode(Ident, &IdentField{S: "main"}),
+				Block: NewNode(Block, &BlockField{Stmts: stmts}),
+			}),
+		}
+	}
+	tests := []struct {
+		name   string
+		in     []*Node
+		expect []string
+	}{
+		{
+			"valid",
+			main(
+				NewNode(Return, &ReturnField{Value: NewNode(Literal, &LiteralField{TType: Integer, I: 32})}),
+			),
+			nil,
+		},
+		{
+			"void return",
+			main(
+				NewNode(Return, &ReturnField{}),
+			),
+			nil,
+		},
+		{
+			"mismatched field",
+			main(
+				NewNode(Return, &BlockField{}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0]: Return node has *interlang.BlockField"},
+		},
+		{
+			"missing child",
+			main(
+				NewNode(Assign, &AssignField{To: NewNode(Ident, &IdentField{S: "x"})}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0].Assign.Value: missing node"},
+		},
+		{
+			"statement as expression",
+			main(
+				NewNode(Return, &ReturnField{Value: NewNode(Block, &BlockField{})}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0].Return.Value: statement Block is used as an expression"},
+		},
+		{
+			"misplaced multiple",
+			main(
+				NewNode(Assign, &AssignField{
+					To:    NewNode(Ident, &IdentField{S: "x"}),
+					Value: NewNode(Multiple, &MultipleField{}),
+				}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0].Assign.Value: Multiple is only allowed as call arguments or return values"},
+		},
+		{
+			"break outside of loop",
+			main(
+				NewNode(Break, &BreakField{}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0].Break: outside of a loop"},
+		},
+		{
+			"unknown label",
+			main(
+				NewNode(While, &WhileField{
+					Cond:  NewNode(Literal, &LiteralField{TType: Integer, I: 1}),
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{NewNode(Continue, &ContinueField{Label: "outer"})}}),
+					Label: "inner",
+				}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0].While.Block.Block.Stmts[0].Continue: unknown loop label: outer"},
+		},
+		{
+			"params",
+			[]*Node{
+				NewNode(FunctionDeclare, &FunctionDeclareField{
+					TType: Integer,
+					Ident: NewNode(Ident, &IdentField{S: "f"}),
+					Params: NewNode(Multiple, &MultipleField{Values: []*Node{
+						NewNode(Param, &ParamField{TType: Integer, Ident: NewNode(Ident, &IdentField{S: "a"}), Default: NewNode(Literal, &LiteralField{TType: Integer, I: 1})}),
+						NewNode(Param, &ParamField{TType: Integer, Ident: NewNode(Ident, &IdentField{S: "b"})}),
+						NewNode(Ident, &IdentField{S: "c"}),
+					}}),
+				}),
+			},
+			[]string{
+				"[0].FunctionDeclare.Params.Multiple.Values[1].Param: parameter without default follows parameter with default",
+				"[0].FunctionDeclare.Params.Multiple.Values[2]: expected Param, found Ident",
+			},
+		},
+		{
+			"address of non-variable",
+			main(
+				NewNode(Return, &ReturnField{Value: NewNode(Unary, &UnaryField{
+					TType:     Integer,
+					Operation: Addr,
+					Value:     NewNode(Literal, &LiteralField{TType: Integer, I: 1}),
+				})}),
+			),
+			[]string{"[0].FunctionDefine.Block.Block.Stmts[0].Return.Value.Unary.Value: expected Ident, found Literal"},
+		},
+		{
+			"expression at toplevel",
+			[]*Node{NewNode(Literal, &LiteralField{TType: Integer, I: 1})},
+			[]string{"[0]: Literal is not allowed at toplevel"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(tt.in) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestValidateProgram(t *testing.T) {
+	p := &Program{
+		Imports: []*Import{{Name: ""}},
+		Types: []*Node{
+			NewNode(TypeDefine, &TypeDefineField{Ident: NewNode(Ident, &IdentField{S: "score"})}),
+		},
+		Globals: []*Node{
+			NewNode(FunctionDeclare, &FunctionDeclareField{TType: Integer, Ident: NewNode(Ident, &IdentField{S: "f"})}),
+		},
+	}
+	var got []string
+	for _, err := range ValidateProgram(p) {
+		got = append(got, err.Error())
+	}
+	expect := []string{
+		"Imports[0]: empty import",
+		"Types[0].TypeDefine: missing type",
+		"Globals[0]: FunctionDeclare is not allowed in Globals",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
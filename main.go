@@ -1,4 +1,230 @@
 package main
 
+import (
+	"cape/interlang"
+	"cape/interlang/dataflow"
+	"cape/interlang/eval"
+	"cape/interlang/fold"
+	"cape/pipeline"
+	"cape/python"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: cape run <program.json>")
+	fmt.Fprintln(os.Stderr, "       cape check <program.json>")
+	fmt.Fprintln(os.Stderr, "       cape python [--dump-after=<pass>] [--time-passes] [--enable=<pass>] [--disable=<pass>] [--type-hints] [--python-version=<x.y>] <program.json>")
+	os.Exit(2)
+}
+
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "run":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		os.Exit(run(os.Args[2]))
+	case "check":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		os.Exit(check(os.Args[2]))
+	case "python":
+		os.Exit(translate(os.Args[2:]))
+	default:
+		usage()
+	}
+}
+
+// run jsonで書き出した中間言語のプログラムを解釈実行する
+func run(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	program, err := interlang.UnmarshalProgram(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	result, err := eval.Run(program.Nodes())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(result.Stdout)
+	return result.ExitCode
+}
+
+// check jsonで書き出した中間言語のプログラムを検査し、エラーと警告を表示する
+// エラーがあれば1を返す
+func check(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	program, err := interlang.UnmarshalProgram(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if errs := interlang.ValidateProgram(program); len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		return 1
+	}
+	for _, fn := range program.Funcs {
+		result, err := dataflow.Analyze(fn.GetField().(*interlang.FunctionDefineField))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", w)
+		}
+	}
+	return 0
+}
+
+// pythonPipeline 中間言語のProgramからpythonのコードを作るパス
+// コードはoptsの設定で生成する
+func pythonPipeline(opts *python.Options) *pipeline.Manager {
+	m := pipeline.New()
+	gen := func(ir any) (string, error) {
+		nodes, ok := ir.([]*python.Node)
+		if !ok {
+			return "", fmt.Errorf("expected python nodes, got %T", ir)
+		}
+		var b strings.Builder
+		if err := python.NewGenerator(*opts).Generate(&b, nodes); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+	dumpProgram := func(ir any) (string, error) {
+		data, err := interlang.MarshalProgram(ir.(*interlang.Program))
+		return string(data), err
+	}
+	passes := []*pipeline.Pass{
+		{
+			Name: "validate",
+			Run: func(ir any) (any, error) {
+				return ir, errors.Join(interlang.ValidateProgram(ir.(*interlang.Program))...)
+			},
+			Dump: dumpProgram,
+		},
+		{
+			Name: "fold",
+			Run: func(ir any) (any, error) {
+				p := ir.(*interlang.Program)
+				folded, err := interlang.NewProgram(fold.Fold(p.Nodes(), fold.All))
+				if err != nil {
+					return nil, err
+				}
+				folded.Source, folded.Imports = p.Source, p.Imports
+				return folded, nil
+			},
+			Dump: dumpProgram,
+		},
+		{
+			Name: "convert",
+			Run: func(ir any) (any, error) {
+				return python.ConvertProgramFromInterLang(ir.(*interlang.Program))
+			},
+			Dump:     gen,
+			Required: true,
+		},
+		{
+			Name: "gen",
+			Run: func(ir any) (any, error) {
+				return gen(ir)
+			},
+			Required: true,
+		},
+	}
+	for _, p := range passes {
+		if err := m.Register(p); err != nil {
+			panic(err)
+		}
+	}
+	// 畳み込みは既定では行わず、--enable=foldで有効にする
+	if err := m.Disable("fold"); err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// translate jsonで書き出した中間言語のプログラムをpythonに翻訳して表示する
+func translate(args []string) int {
+	var opts python.Options
+	m := pythonPipeline(&opts)
+	flags := flag.NewFlagSet("python", flag.ContinueOnError)
+	flags.BoolVar(&opts.TypeHints, "type-hints", false, "annotate parameters, return values and declarations with PEP 484 type hints")
+	version := flags.String("python-version", "", "target python version such as 3.8; syntax newer than it is an error")
+	dumpAfter := flags.String("dump-after", "", "comma separated passes to dump the IR after, or all ("+strings.Join(m.Names(), ", ")+")")
+	enable := flags.String("enable", "", "comma separated passes to run that are off by default (fold)")
+	disable := flags.String("disable", "", "comma separated passes to skip")
+	flags.BoolVar(&m.Time, "time-passes", false, "report the time taken by each pass")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		usage()
+	}
+	if *version != "" {
+		if _, err := fmt.Sscanf(*version, "%d.%d", &opts.Version.Major, &opts.Version.Minor); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid python version: %s\n", *version)
+			return 2
+		}
+	}
+	for _, name := range splitList(*dumpAfter) {
+		if err := m.DumpAfter(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	for _, name := range splitList(*enable) {
+		if err := m.Enable(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	for _, name := range splitList(*disable) {
+		if err := m.Disable(name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	program, err := interlang.UnmarshalProgram(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	code, err := m.Run(program)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(code)
+	return 0
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
 }
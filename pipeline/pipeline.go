@@ -0,0 +1,154 @@
+// Package pipeline 翻訳の各段階を名前付きのパスとして順に実行する
+//
+// パスは直前のパスの結果を受け取り、次のパスへ渡す結果を返す
+// 結果の型はパスによって異なる(トークン列、構文木、中間言語、生成したコードなど)
+package pipeline
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Pass 翻訳の一段階
+type Pass struct {
+	Name string
+	Run  func(ir any) (any, error)
+	// Dump 結果を表示用の文字列にする
+	// nilならfmtの%vで表示する
+	Dump func(ir any) (string, error)
+	// Required 結果の型を変えるなど、飛ばすと次のパスが受け取れなくなる
+	// Disableで無効にできない
+	Required bool
+}
+
+// Manager パスを登録した順に実行する
+type Manager struct {
+	passes   []*Pass
+	disabled map[string]bool
+	dump     map[string]bool
+	// Time パスごとの実行時間を表示する
+	Time bool
+	// Out IRのダンプと実行時間の出力先
+	Out io.Writer
+}
+
+// New パスを持たないManagerを作る
+func New() *Manager {
+	return &Manager{
+		disabled: map[string]bool{},
+		dump:     map[string]bool{},
+		Out:      os.Stderr,
+	}
+}
+
+// Register パスを末尾に加える
+func (m *Manager) Register(p *Pass) error {
+	if m.lookup(p.Name) != nil {
+		return fmt.Errorf("pass %s is already registered", p.Name)
+	}
+	m.passes = append(m.passes, p)
+	return nil
+}
+
+// Names 登録したパスの名前を実行順に返す
+func (m *Manager) Names() []string {
+	var names []string
+	for _, p := range m.passes {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func (m *Manager) lookup(name string) *Pass {
+	for _, p := range m.passes {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func (m *Manager) known(name string) error {
+	if m.lookup(name) == nil {
+		return fmt.Errorf("unknown pass: %s", name)
+	}
+	return nil
+}
+
+// Enable 無効にしたパスを再び実行するようにする
+func (m *Manager) Enable(name string) error {
+	if err := m.known(name); err != nil {
+		return err
+	}
+	delete(m.disabled, name)
+	return nil
+}
+
+// Disable パスを飛ばし、直前の結果をそのまま次のパスへ渡す
+func (m *Manager) Disable(name string) error {
+	if err := m.known(name); err != nil {
+		return err
+	}
+	if m.lookup(name).Required {
+		return fmt.Errorf("pass %s cannot be disabled", name)
+	}
+	m.disabled[name] = true
+	return nil
+}
+
+// DumpAfter パスを実行した後の結果を表示する
+// "all"なら全てのパスの後で表示する
+func (m *Manager) DumpAfter(name string) error {
+	if name != "all" {
+		if err := m.known(name); err != nil {
+			return err
+		}
+	}
+	m.dump[name] = true
+	return nil
+}
+
+// Run 有効なパスを順に実行し、最後のパスの結果を返す
+func (m *Manager) Run(ir any) (any, error) {
+	var total time.Duration
+	for _, p := range m.passes {
+		if m.disabled[p.Name] {
+			continue
+		}
+		start := time.Now()
+		out, err := p.Run(ir)
+		elapsed := time.Since(start)
+		total += elapsed
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+		ir = out
+		if m.Time {
+			fmt.Fprintf(m.Out, "pass %-12s %v\n", p.Name, elapsed)
+		}
+		if m.dump["all"] || m.dump[p.Name] {
+			if err := m.dumpIR(p, ir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if m.Time {
+		fmt.Fprintf(m.Out, "pass %-12s %v\n", "(total)", total)
+	}
+	return ir, nil
+}
+
+func (m *Manager) dumpIR(p *Pass, ir any) error {
+	s := fmt.Sprintf("%v", ir)
+	if p.Dump != nil {
+		var err error
+		s, err = p.Dump(ir)
+		if err != nil {
+			return fmt.Errorf("%s: dump: %w", p.Name, err)
+		}
+	}
+	fmt.Fprintf(m.Out, "*** IR dump after %s ***\n%s\n", p.Name, s)
+	return nil
+}
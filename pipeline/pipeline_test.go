@@ -0,0 +1,139 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func newManager(t *testing.T) (*Manager, *bytes.Buffer) {
+	t.Helper()
+	m := New()
+	var out bytes.Buffer
+	m.Out = &out
+	passes := []*Pass{
+		{Name: "double", Run: func(ir any) (any, error) { return ir.(int) * 2, nil }},
+		{Name: "inc", Run: func(ir any) (any, error) { return ir.(int) + 1, nil }},
+		{
+			Name: "show",
+			Run:  func(ir any) (any, error) { return fmt.Sprintf("<%d>", ir.(int)), nil },
+			Dump: func(ir any) (string, error) { return "string " + ir.(string), nil },
+			// 結果をintからstringにする
+			Required: true,
+		},
+	}
+	for _, p := range passes {
+		if err := m.Register(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m, &out
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		disable  []string
+		dump     []string
+		expect   any
+		expectIR string
+	}{
+		{"all", nil, nil, "<7>", ""},
+		{"disable", []string{"double"}, nil, "<4>", ""},
+		{
+			"dump",
+			nil,
+			[]string{"inc", "show"},
+			"<7>",
+			"*** IR dump after inc ***\n7\n*** IR dump after show ***\nstring <7>\n",
+		},
+		{
+			"dump all",
+			[]string{"inc"},
+			[]string{"all"},
+			"<6>",
+			"*** IR dump after double ***\n6\n*** IR dump after show ***\nstring <6>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, out := newManager(t)
+			for _, name := range tt.disable {
+				if err := m.Disable(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, name := range tt.dump {
+				if err := m.DumpAfter(name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := m.Run(3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+			if diff := cmp.Diff(tt.expectIR, out.String()); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestEnable(t *testing.T) {
+	m, _ := newManager(t)
+	if err := m.Disable("inc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Enable("inc"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.Run(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "<3>" {
+		t.Fatalf("unexpected result: %v", got)
+	}
+}
+
+func TestTime(t *testing.T) {
+	m, out := newManager(t)
+	m.Time = true
+	if _, err := m.Run(1); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		names = append(names, strings.Fields(line)[1])
+	}
+	if diff := cmp.Diff([]string{"double", "inc", "show", "(total)"}, names); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	m, _ := newManager(t)
+	if err := m.Register(&Pass{Name: "inc"}); err == nil {
+		t.Fatal("expected duplicate pass error")
+	}
+	if err := m.DumpAfter("missing"); err == nil {
+		t.Fatal("expected unknown pass error")
+	}
+	if err := m.Disable("missing"); err == nil {
+		t.Fatal("expected unknown pass error")
+	}
+	if err := m.Disable("show"); err == nil || err.Error() != "pass show cannot be disabled" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := m.Register(&Pass{Name: "fail", Run: func(any) (any, error) { return nil, fmt.Errorf("broken") }}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Run(1); err == nil || err.Error() != "fail: broken" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package python
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	orig := NewNode(FunctionDefine, &FunctionDefineField{
+		Ident: NewNode(Ident, &IdentField{S: "main"}),
+		Block: NewNode(Block, &BlockField{Stmts: []*Node{
+			NewNode(Global, &GlobalField{Names: []string{"count"}}),
+			NewNode(While, &WhileField{Block: NewNode(Block, &BlockField{Stmts: []*Node{NewNode(Break, &BreakField{})}}), Label: "loop"}),
+		}}),
+	}).WithSpan(Span{Line: 1, Col: 1})
+	cloned := orig.Clone()
+	if diff := cmp.Diff(orig, cloned); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+
+	stmts := cloned.GetField().(*FunctionDefineField).Block.GetField().(*BlockField).Stmts
+	stmts[0].GetField().(*GlobalField).Names[0] = "other"
+	if Equal(orig, cloned, EqualOptions{}) {
+		t.Fatal("clone shares names with the original")
+	}
+	stmts[0].GetField().(*GlobalField).Names[0] = "count"
+	cloned.Span = Span{}
+	if Equal(orig, cloned, EqualOptions{}) || !Equal(orig, cloned, EqualOptions{IgnoreSpan: true}) {
+		t.Fatal("unexpected span comparison")
+	}
+}
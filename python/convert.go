@@ -2,90 +2,201 @@ package python
 
 import (
 	"cape/interlang"
-	"log"
+	"cape/interlang/dataflow"
+	"fmt"
 )
 
+// converter 一回の変換の状態
+type converter struct {
+	// uninitialized 変換中の関数で、代入される前に読まれるかもしれない変数の宣言
+	// Cでは不定値が読まれるが、pythonではUnboundLocalErrorになるので明示的に初期化する
+	uninitialized map[*interlang.Node]bool
+	// function 変換中の関数
+	function *interlang.FunctionDefineField
+	// renames 変換中のプログラムで、予約語と衝突するために付け替える名前
+	renames map[string]string
+	// globals 大域変数の型
+	globals map[string]interlang.TType
+	// locals 変換中の関数の局所変数と仮引数の型
+	locals map[string]interlang.TType
+}
+
+// ConvertNodeFromInterLang トップレベルのノードの並びをProgramにまとめてから変換する
 func ConvertNodeFromInterLang(iNodes []*interlang.Node) ([]*Node, error) {
-	var nodes []*Node
-	for _, in := range iNodes {
-		pn, err := toplevel(in)
-		if err != nil {
-			return nil, err
-		}
-		// 中間言語にあるけどpythonにないもの
-		if pn == nil {
-			continue
-		}
-		nodes = append(nodes, pn)
+	p, err := interlang.NewProgram(iNodes)
+	if err != nil {
+		return nil, err
 	}
-	return nodes, nil
+	return ConvertProgramFromInterLang(p)
 }
 
+// ConvertTypeFromInterLang 中間言語の型をpythonの型にする
+// ポインタと配列はlist、構造体はクラス、voidはNoneになる
+// 対応する型がなければnilを返す
 func ConvertTypeFromInterLang(tt interlang.TType) (TType, error) {
-	switch tt {
-	case interlang.Integer:
-		return Integer, nil
-	case interlang.String:
-		return String, nil
-	case interlang.Bool:
-		return Bool, nil
-	default:
-		return nil, nil
-	}
+	return (&converter{}).convertType(tt)
 }
 
-func toplevel(iNode *interlang.Node) (*Node, error) {
-	switch iNode.GetKind() {
-	case interlang.FunctionDefine:
-		return functionDefine(iNode)
-	default:
-		return nil, nil
+// convertType 構造体の名前を付け替えて型を変換する
+func (c *converter) convertType(tt interlang.TType) (TType, error) {
+	switch tt := tt.(type) {
+	case interlang.TPrimitive:
+		switch tt {
+		case interlang.Integer:
+			return Integer, nil
+		case interlang.String:
+			return String, nil
+		case interlang.Bool:
+			return Bool, nil
+		case interlang.Null:
+			return Null, nil
+		case interlang.Float:
+			return Float, nil
+		}
+	case interlang.TTuple:
+		var tuple TTuple
+		for _, elem := range tt {
+			t, err := c.convertType(elem)
+			if err != nil {
+				return nil, err
+			}
+			tuple = append(tuple, t)
+		}
+		return tuple, nil
+	case interlang.TPointer:
+		elem, err := c.convertType(tt.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return TList{Elem: elem}, nil
+	case interlang.TArray:
+		elem, err := c.convertType(tt.Elem)
+		if err != nil {
+			return nil, err
+		}
+		return TList{Elem: elem}, nil
+	case interlang.TStruct:
+		return TClass{Name: c.renamed(tt.Name)}, nil
 	}
+	return nil, nil
 }
 
-func functionDefine(iNode *interlang.Node) (*Node, error) {
+// newNode 中間言語のノードの位置を引き継いだノードを作る
+func newNode(iNode *interlang.Node, kind NodeKind, field Field) *Node {
+	return NewNode(kind, field).WithSpan(Span(iNode.GetSpan()))
+}
+
+func (c *converter) functionDefine(iNode *interlang.Node) (*Node, error) {
 	iField := iNode.GetField().(*interlang.FunctionDefineField)
-	// typeの変換
-	//iReturnValueType := iField.GetTType()
-	//returnValueType, err := ConvertTypeFromInterLang(iReturnValueType)
-	var returnValueType TType = nil
+	returnValueType, err := c.convertType(iField.GetTType())
+	if err != nil {
+		return nil, err
+	}
 
-	iIdentField := iField.Ident.GetField().(*interlang.IdentField)
-	ident := iIdentField.S
+	c.function = iField
+	c.locals = localTypes(iField)
+	result, err := dataflow.Analyze(iField)
+	if err != nil {
+		return nil, err
+	}
+	c.uninitialized = map[*interlang.Node]bool{}
+	for _, decl := range result.Uninitialized {
+		c.uninitialized[decl] = true
+	}
+	for _, decl := range inputDeclares(iField) {
+		c.uninitialized[decl] = true
+	}
 
-	params, err := functionDefineParams(iField.Params)
+	params, err := c.functionDefineParams(iField.Params)
 	if err != nil {
 		return nil, err
 	}
 
-	stmts, err := statement(iField.Block)
+	stmts, err := c.statement(iField.Block)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewNode(
+	return newNode(
+		iNode,
 		FunctionDefine,
 		&FunctionDefineField{
 			returnValueType,
-			NewNode(Ident, &IdentField{S: ident}),
+			c.ident(iField.Ident),
 			params,
 			stmts,
 		},
 	), nil
 }
 
-func functionDefineParams(iNode *interlang.Node) (*Node, error) {
-	// TODO
-	return nil, nil
+// localTypes 関数の仮引数と局所変数の型
+// 同じ名前を宣言し直している場合は最初の宣言の型にする
+func localTypes(iField *interlang.FunctionDefineField) map[string]interlang.TType {
+	types := map[string]interlang.TType{}
+	declare := func(iIdent *interlang.Node, tt interlang.TType) {
+		if _, ok := types[identName(iIdent)]; !ok {
+			types[identName(iIdent)] = tt
+		}
+	}
+	if iField.Params != nil {
+		for _, iParam := range iField.Params.GetField().(*interlang.MultipleField).Values {
+			iParamField := iParam.GetField().(*interlang.ParamField)
+			declare(iParamField.Ident, iParamField.GetTType())
+		}
+	}
+	interlang.Walk(iField.Block, func(n *interlang.Node) bool {
+		switch iField := n.GetField().(type) {
+		case *interlang.VariableDeclareField:
+			declare(iField.Ident, iField.GetTType())
+		case *interlang.VariableDefineField:
+			declare(iField.Ident, iField.GetTType())
+		}
+		return true
+	})
+	return types
+}
+
+// declaredType 変換中の関数の局所変数、仮引数、大域変数の宣言の型
+// 見つからなければnilを返す
+func (c *converter) declaredType(name string) interlang.TType {
+	if tt, ok := c.locals[name]; ok {
+		return tt
+	}
+	return c.globals[name]
+}
+
+// functionDefineParams 仮引数の並び
+// 引数がなければnilを返す
+func (c *converter) functionDefineParams(iNode *interlang.Node) (*Node, error) {
+	if iNode == nil {
+		return nil, nil
+	}
+	var params []*Node
+	for _, iParam := range iNode.GetField().(*interlang.MultipleField).Values {
+		iParamField := iParam.GetField().(*interlang.ParamField)
+		tt, err := c.convertType(iParamField.GetTType())
+		if err != nil {
+			return nil, err
+		}
+		value, err := optional(c.expr, iParamField.Default)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, newNode(iParam, Param, &ParamField{tt, c.ident(iParamField.Ident), value}))
+	}
+	if len(params) == 0 {
+		return nil, nil
+	}
+	return newNode(iNode, Multiple, &MultipleField{Values: params}), nil
 }
 
-func statement(iNode *interlang.Node) (*Node, error) {
+func (c *converter) statement(iNode *interlang.Node) (*Node, error) {
 	switch iNode.GetKind() {
 	case interlang.Block:
 		var stmts []*Node
 		iStmts := iNode.GetField().(*interlang.BlockField).Stmts
 		for _, iStmt := range iStmts {
-			stmt, err := statement(iStmt)
+			stmt, err := c.statement(iStmt)
 			if err != nil {
 				return nil, err
 			}
@@ -94,277 +205,299 @@ func statement(iNode *interlang.Node) (*Node, error) {
 			}
 			stmts = append(stmts, stmt)
 		}
-		return NewNode(Block, &BlockField{stmts}), nil
+		return newNode(iNode, Block, &BlockField{stmts}), nil
 
 	case interlang.Return:
 		iReturnField := iNode.GetField().(*interlang.ReturnField)
-		rv, err := expr(iReturnField.Value)
+		// voidの関数のreturn;は値を持たない
+		rv, err := optional(c.expr, iReturnField.Value)
 		if err != nil {
 			return nil, err
 		}
-		return NewNode(Return, &ReturnField{Value: rv}), nil
+		return newNode(iNode, Return, &ReturnField{Value: rv}), nil
 
 	case interlang.IfElse:
 		iIfElseField := iNode.GetField().(*interlang.IfElseField)
-		cond, err := expr(iIfElseField.Cond)
+		cond, err := c.expr(iIfElseField.Cond)
 		if err != nil {
 			return nil, err
 		}
-		ifBlock, err := statement(iIfElseField.IfBlock)
+		ifBlock, err := c.statement(iIfElseField.IfBlock)
 		if err != nil {
 			return nil, err
 		}
 		// ifだけ
 		if iIfElseField.ElseBlock == nil {
-			return NewNode(IfElse, &IfElseField{cond, ifBlock, nil}), nil
+			return newNode(iNode, IfElse, &IfElseField{cond, ifBlock, nil}), nil
 		}
 		// elseあり
-		elseBlock, err := statement(iIfElseField.ElseBlock)
+		elseBlock, err := c.statement(iIfElseField.ElseBlock)
 		if err != nil {
 			return nil, err
 		}
-		return NewNode(IfElse, &IfElseField{cond, ifBlock, elseBlock}), nil
+		return newNode(iNode, IfElse, &IfElseField{cond, ifBlock, elseBlock}), nil
 
 	case interlang.While:
-		// TODO
-		panic("unhandled default case")
+		iWhileField := iNode.GetField().(*interlang.WhileField)
+		cond, err := c.expr(iWhileField.Cond)
+		if err != nil {
+			return nil, err
+		}
+		block, err := c.statement(iWhileField.Block)
+		if err != nil {
+			return nil, err
+		}
+		return newNode(iNode, While, &WhileField{cond, block, iWhileField.Label}), nil
 	case interlang.For:
+		if node, ok, err := c.rangeFor(iNode); ok || err != nil {
+			return node, err
+		}
 		iForField := iNode.GetField().(*interlang.ForField)
-		init, err := expr(iForField.Init)
+		init, err := optional(c.expr, iForField.Init)
 		if err != nil {
 			return nil, err
 		}
-		cond, err := expr(iForField.Cond)
+		cond, err := optional(c.expr, iForField.Cond)
 		if err != nil {
 			return nil, err
 		}
-		loop, err := expr(iForField.Loop)
+		loop, err := optional(c.expr, iForField.Loop)
 		if err != nil {
 			return nil, err
 		}
-		block, err := statement(iForField.Block)
+		block, err := c.statement(iForField.Block)
 		if err != nil {
 			return nil, err
 		}
-		return NewNode(For, &ForField{init, cond, loop, block}), nil
+		return newNode(iNode, For, &ForField{init, cond, loop, block, iForField.Label}), nil
+	case interlang.Break:
+		iBreakField := iNode.GetField().(*interlang.BreakField)
+		return newNode(iNode, Break, &BreakField{iBreakField.Label}), nil
+	case interlang.Continue:
+		iContinueField := iNode.GetField().(*interlang.ContinueField)
+		return newNode(iNode, Continue, &ContinueField{iContinueField.Label}), nil
 	default:
-		return expr(iNode)
+		return c.expr(iNode)
 	}
 }
 
-func expr(iNode *interlang.Node) (*Node, error) {
-	return assign(iNode)
+func (c *converter) expr(iNode *interlang.Node) (*Node, error) {
+	return c.assign(iNode)
 }
 
-func assign(iNode *interlang.Node) (*Node, error) {
-	switch iNode.GetKind() {
-	case interlang.VariableDeclare:
-		// TODO
-		// pythonには存在しない概念
+// optional 省略可能な子ノードを変換する
+func optional(convert func(*interlang.Node) (*Node, error), iNode *interlang.Node) (*Node, error) {
+	if iNode == nil {
 		return nil, nil
-	case interlang.Assign:
-		iAssignField := iNode.GetField().(*interlang.AssignField)
-		to, err := expr(iAssignField.To)
-		if err != nil {
-			return nil, err
-		}
-		value, err := expr(iAssignField.Value)
-		if err != nil {
-			return nil, err
-		}
-		return NewNode(Assign, &AssignField{to, value}), nil
-	default:
-		return andor(iNode)
 	}
+	return convert(iNode)
 }
 
-func andor(iNode *interlang.Node) (*Node, error) {
+func (c *converter) assign(iNode *interlang.Node) (*Node, error) {
 	switch iNode.GetKind() {
-	case interlang.Binary:
-		iBinaryField := iNode.GetField().(*interlang.BinaryField)
-		iType := iBinaryField.GetTType()
-		pType, err := ConvertTypeFromInterLang(iType)
-		if err != nil {
-			return nil, err
-		}
-		lhs, err := andor(iBinaryField.LHS)
-		if err != nil {
+	case interlang.VariableDeclare:
+		// pythonに宣言はないので、代入より先に読まれうる場合だけゼロ値で初期化する
+		if c.uninitialized[iNode] {
+			return c.zeroAssign(iNode)
+		}
+		// それ以外は型ヒントを付ける場合のために値のない代入として残す
+		iDeclareField := iNode.GetField().(*interlang.VariableDeclareField)
+		tt, err := c.convertType(iDeclareField.GetTType())
+		if err != nil || tt == nil {
 			return nil, err
 		}
-		rhs, err := andor(iBinaryField.RHS)
+		return newNode(iNode, Assign, &AssignField{c.ident(iDeclareField.Ident), nil, tt}), nil
+	case interlang.VariableDefine:
+		// 初期値付きの宣言はただの代入になる
+		iDefineField := iNode.GetField().(*interlang.VariableDefineField)
+		value, err := c.expr(iDefineField.Value)
 		if err != nil {
 			return nil, err
 		}
-		switch iBinaryField.Operation {
-		case interlang.And:
-			return NewNode(Binary, &BinaryField{pType, And, lhs, rhs}), nil
-		case interlang.Or:
-			return NewNode(Binary, &BinaryField{pType, Or, lhs, rhs}), nil
-		default:
-			return equality(iNode)
-		}
-	default:
-		return equality(iNode)
-	}
-}
-
-func equality(iNode *interlang.Node) (*Node, error) {
-	switch iNode.GetKind() {
-	case interlang.Binary:
-		iBinaryField := iNode.GetField().(*interlang.BinaryField)
-		iType := iBinaryField.GetTType()
-		pType, err := ConvertTypeFromInterLang(iType)
+		tt, err := c.convertType(iDefineField.GetTType())
 		if err != nil {
 			return nil, err
 		}
-		lhs, err := equality(iBinaryField.LHS)
+		return newNode(iNode, Assign, &AssignField{c.ident(iDefineField.Ident), value, tt}), nil
+	case interlang.Assign:
+		iAssignField := iNode.GetField().(*interlang.AssignField)
+		to, err := c.expr(iAssignField.To)
 		if err != nil {
 			return nil, err
 		}
-		rhs, err := equality(iBinaryField.RHS)
+		value, err := c.expr(iAssignField.Value)
 		if err != nil {
 			return nil, err
 		}
-		switch iBinaryField.Operation {
-		case interlang.Eq:
-			return NewNode(Binary, &BinaryField{pType, Eq, lhs, rhs}), nil
-		case interlang.Ne:
-			return NewNode(Binary, &BinaryField{pType, Ne, lhs, rhs}), nil
-		default:
-			return relational(iNode)
-		}
+		return newNode(iNode, Assign, &AssignField{to, value, nil}), nil
 	default:
-		return relational(iNode)
+		return c.binary(iNode)
 	}
 }
 
-func relational(iNode *interlang.Node) (*Node, error) {
-	switch iNode.GetKind() {
-	case interlang.Binary:
-		iBinaryField := iNode.GetField().(*interlang.BinaryField)
-		iType := iBinaryField.GetTType()
-		pType, err := ConvertTypeFromInterLang(iType)
-		if err != nil {
-			return nil, err
-		}
-		lhs, err := relational(iBinaryField.LHS)
-		if err != nil {
-			return nil, err
-		}
-		rhs, err := relational(iBinaryField.RHS)
-		if err != nil {
-			return nil, err
-		}
-		switch iBinaryField.Operation {
-		case interlang.Lt:
-			return NewNode(Binary, &BinaryField{pType, Lt, lhs, rhs}), nil
-		case interlang.Le:
-			return NewNode(Binary, &BinaryField{pType, Le, lhs, rhs}), nil
-		case interlang.Gt:
-			return NewNode(Binary, &BinaryField{pType, Gt, lhs, rhs}), nil
-		case interlang.Ge:
-			return NewNode(Binary, &BinaryField{pType, Ge, lhs, rhs}), nil
-		default:
-			return add(iNode)
-		}
+// binary 二項演算
+// 優先順位はgenが括弧で表すので、演算子ごとに一度だけ左右を変換する
+func (c *converter) binary(iNode *interlang.Node) (*Node, error) {
+	if iNode.GetKind() != interlang.Binary {
+		return c.unary(iNode)
+	}
+	iBinaryField := iNode.GetField().(*interlang.BinaryField)
+	var op Operation
+	switch iBinaryField.Operation {
+	case interlang.And:
+		op = And
+	case interlang.Or:
+		op = Or
+	case interlang.BitOr:
+		op = BitOr
+	case interlang.BitXor:
+		op = BitXor
+	case interlang.BitAnd:
+		op = BitAnd
+	case interlang.Eq:
+		op = Eq
+	case interlang.Ne:
+		op = Ne
+	case interlang.Lt:
+		op = Lt
+	case interlang.Le:
+		op = Le
+	case interlang.Gt:
+		op = Gt
+	case interlang.Ge:
+		op = Ge
+	case interlang.Shl:
+		op = Shl
+	case interlang.Shr:
+		op = Shr
+	case interlang.Add:
+		op = Add
+	case interlang.Sub:
+		op = Sub
+	case interlang.Mul:
+		op = Mul
+	case interlang.Div:
+		op = Div
+	case interlang.Mod:
+		op = Mod
 	default:
-		return add(iNode)
+		return nil, fmt.Errorf("%v: unexpected binary operation: %v", iNode.GetSpan(), iBinaryField.Operation)
+	}
+	pType, err := c.convertType(iBinaryField.GetTType())
+	if err != nil {
+		return nil, err
 	}
+	lhs, err := c.binary(iBinaryField.LHS)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := c.binary(iBinaryField.RHS)
+	if err != nil {
+		return nil, err
+	}
+	return newNode(iNode, Binary, &BinaryField{pType, op, lhs, rhs}), nil
 }
 
-func add(iNode *interlang.Node) (*Node, error) {
+func (c *converter) unary(iNode *interlang.Node) (*Node, error) {
 	switch iNode.GetKind() {
-	case interlang.Binary:
-		iBinaryField := iNode.GetField().(*interlang.BinaryField)
-		iType := iBinaryField.GetTType()
-		pType, err := ConvertTypeFromInterLang(iType)
+	case interlang.Not:
+		iNotField := iNode.GetField().(*interlang.NotField)
+		value, err := c.unary(iNotField.Value)
 		if err != nil {
 			return nil, err
 		}
-		lhs, err := add(iBinaryField.LHS)
+		return newNode(iNode, Not, &NotField{value}), nil
+	case interlang.Unary:
+		iUnaryField := iNode.GetField().(*interlang.UnaryField)
+		pType, err := c.convertType(iUnaryField.GetTType())
 		if err != nil {
 			return nil, err
 		}
-		rhs, err := add(iBinaryField.RHS)
+		value, err := c.unary(iUnaryField.Value)
 		if err != nil {
 			return nil, err
 		}
-		switch iBinaryField.Operation {
-		case interlang.Add:
-			return NewNode(Binary, &BinaryField{pType, Add, lhs, rhs}), nil
-		case interlang.Sub:
-			return NewNode(Binary, &BinaryField{pType, Sub, lhs, rhs}), nil
+		switch iUnaryField.Operation {
+		case interlang.Neg:
+			return newNode(iNode, Unary, &UnaryField{pType, Neg, value}), nil
+		case interlang.Plus:
+			return newNode(iNode, Unary, &UnaryField{pType, Plus, value}), nil
+		case interlang.BitNot:
+			return newNode(iNode, Unary, &UnaryField{pType, BitNot, value}), nil
+		case interlang.Addr:
+			// scanfの格納先としてだけ使う
+			return newNode(iNode, Unary, &UnaryField{pType, Addr, value}), nil
 		default:
-			return mul(iNode)
+			return nil, fmt.Errorf("%v: unexpected unary operation: %v", iNode.GetSpan(), iUnaryField.Operation)
 		}
 	default:
-		return mul(iNode)
+		return c.primary(iNode)
 	}
 }
 
-func mul(iNode *interlang.Node) (*Node, error) {
+func (c *converter) primary(iNode *interlang.Node) (*Node, error) {
 	switch iNode.GetKind() {
-	case interlang.Binary:
-		iBinaryField := iNode.GetField().(*interlang.BinaryField)
-		iType := iBinaryField.GetTType()
-		pType, err := ConvertTypeFromInterLang(iType)
-		if err != nil {
-			return nil, err
-		}
-		lhs, err := mul(iBinaryField.LHS)
-		if err != nil {
-			return nil, err
+	case interlang.Ident:
+		if node := stdioMacro(iNode); node != nil {
+			return node, nil
 		}
-		rhs, err := mul(iBinaryField.RHS)
-		if err != nil {
-			return nil, err
+		// 除算の種類を決めるために、式の中の識別子には型を付ける
+		node := c.ident(iNode)
+		tt := iNode.GetField().(*interlang.IdentField).TType
+		if tt == nil {
+			tt = c.declaredType(identName(iNode))
 		}
-		switch iBinaryField.Operation {
-		case interlang.Mul:
-			return NewNode(Binary, &BinaryField{pType, Mul, lhs, rhs}), nil
-		case interlang.Div:
-			return NewNode(Binary, &BinaryField{pType, Div, lhs, rhs}), nil
-		case interlang.Mod:
-			return NewNode(Binary, &BinaryField{pType, Mod, lhs, rhs}), nil
-		default:
-			return unary(iNode)
+		if tt, err := c.convertType(tt); err == nil {
+			node.GetField().(*IdentField).TType = tt
 		}
-	default:
-		return unary(iNode)
-	}
-}
-
-func unary(iNode *interlang.Node) (*Node, error) {
-	return primary(iNode)
-}
-
-func primary(iNode *interlang.Node) (*Node, error) {
-	switch iNode.GetKind() {
-	case interlang.Ident:
-		iIdentField := iNode.GetField().(*interlang.IdentField)
-		return NewNode(Ident, &IdentField{S: iIdentField.S}), nil
+		return node, nil
 	case interlang.Literal:
 		return literal(iNode)
 	case interlang.Call:
-		log.Panicf("call unimplemented: %v", iNode)
+		return c.call(iNode)
+	case interlang.Assign:
+		// (c = getchar()) != EOFのような式の中の代入
+		return c.assign(iNode)
+	case interlang.Binary, interlang.Unary, interlang.Not:
+		// 外側より弱く結びつく演算(Cのソースでは括弧で囲まれていたもの)
+		return c.binary(iNode)
 	default:
-		log.Panicf("unexpected primary node: %v", iNode)
+		return nil, fmt.Errorf("%v: unexpected primary node: %v", iNode.GetSpan(), iNode.GetKind())
 	}
-	return nil, nil
+}
+
+func (c *converter) call(iNode *interlang.Node) (*Node, error) {
+	iCallField := iNode.GetField().(*interlang.CallField)
+	tt, err := c.convertType(iCallField.GetTType())
+	if err != nil {
+		return nil, err
+	}
+	var args []*Node
+	if iCallField.Args != nil {
+		for _, iArg := range iCallField.Args.GetField().(*interlang.MultipleField).Values {
+			arg, err := c.expr(iArg)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+	// 引数がなくてもgenCallが辿れるようMultipleを置く
+	return newNode(iNode, Call, &CallField{tt, c.ident(iCallField.Ident), newNode(iNode, Multiple, &MultipleField{Values: args})}), nil
 }
 
 func literal(iNode *interlang.Node) (*Node, error) {
 	iLitField := iNode.GetField().(*interlang.LiteralField)
 	switch iLitField.GetTType() {
 	case interlang.String:
-		return NewNode(Literal, &LiteralField{TType: String, S: iLitField.S}), nil
+		return newNode(iNode, Literal, &LiteralField{TType: String, S: iLitField.S}), nil
 	case interlang.Integer:
-		return NewNode(Literal, &LiteralField{TType: Integer, I: iLitField.I}), nil
+		return newNode(iNode, Literal, &LiteralField{TType: Integer, I: iLitField.I}), nil
 	case interlang.Bool:
-		//return NewNode(Literal, &LiteralField{TType: B, S: iLitField.S}), nil
-		log.Panicf("unsupported literal: %v", iNode)
+		return newNode(iNode, Literal, &LiteralField{TType: Bool, I: iLitField.I}), nil
+	case interlang.Float:
+		return newNode(iNode, Literal, &LiteralField{TType: Float, F: iLitField.F}), nil
 	default:
-		log.Panicf("unsupported literal: %v", iNode)
+		return nil, fmt.Errorf("%v: unsupported literal: %v", iNode.GetSpan(), iLitField.GetTType())
 	}
-	return nil, nil
 }
@@ -2,7 +2,10 @@ package python
 
 import (
 	"cape/interlang"
+	"cape/interlang/build"
+	"fmt"
 	"github.com/google/go-cmp/cmp"
+	"sync"
 	"testing"
 )
 
@@ -15,16 +18,11 @@ func TestConvertNodeFromInterLang(t *testing.T) {
 		{
 			"def",
 			[]*interlang.Node{
-				interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
-					TType:  interlang.Integer,
-					Ident:  interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "main"}),
-					Params: nil,
-					Block:  interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: nil}),
-				}),
+				build.Func("main", build.Int, build.Params(), build.Block()),
 			},
 			[]*Node{
 				NewNode(FunctionDefine, &FunctionDefineField{
-					TType:  nil,
+					TType:  Integer,
 					Ident:  NewNode(Ident, &IdentField{S: "main"}),
 					Params: nil,
 					Block:  NewNode(Block, &BlockField{Stmts: nil}),
@@ -34,26 +32,83 @@ func TestConvertNodeFromInterLang(t *testing.T) {
 		{
 			"int",
 			[]*interlang.Node{
-				interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
-					TType:  interlang.Integer,
-					Ident:  interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "main"}),
+				build.Func("main", build.Int, build.Params(), build.Block(
+					build.Return(build.IntLit(32)),
+				)),
+			},
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  Integer,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
 					Params: nil,
-					Block: interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: []*interlang.Node{
-						interlang.NewNode(interlang.Return, &interlang.ReturnField{Value: interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 32})}),
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(Return, &ReturnField{Value: NewNode(Literal, &LiteralField{TType: Integer, I: 32})}),
 					}}),
 				}),
 			},
+		},
+		{
+			"unary",
+			[]*interlang.Node{
+				build.Func("main", build.Int, build.Params(), build.Block(
+					build.Return(build.Bin(build.BitAnd, build.Un(build.Neg, build.Var("x", build.Int)), build.Un(build.BitNot, build.IntLit(1)))),
+				)),
+			},
 			[]*Node{
 				NewNode(FunctionDefine, &FunctionDefineField{
-					TType:  nil,
+					TType:  Integer,
 					Ident:  NewNode(Ident, &IdentField{S: "main"}),
 					Params: nil,
 					Block: NewNode(Block, &BlockField{Stmts: []*Node{
-						NewNode(Return, &ReturnField{Value: NewNode(Literal, &LiteralField{TType: Integer, I: 32})}),
+						NewNode(Return, &ReturnField{Value: NewNode(Binary, &BinaryField{
+							TType:     Integer,
+							Operation: BitAnd,
+							LHS:       NewNode(Unary, &UnaryField{TType: Integer, Operation: Neg, Value: NewNode(Ident, &IdentField{TType: Integer, S: "x"})}),
+							RHS:       NewNode(Unary, &UnaryField{TType: Integer, Operation: BitNot, Value: NewNode(Literal, &LiteralField{TType: Integer, I: 1})}),
+						})}),
 					}}),
 				}),
 			},
 		},
+		{
+			"void return",
+			[]*interlang.Node{
+				build.Func("f", interlang.Null, build.Params(), build.Block(build.Return(nil))),
+			},
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  Null,
+					Ident:  NewNode(Ident, &IdentField{S: "f"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(Return, &ReturnField{}),
+					}}),
+				}),
+			},
+		},
+		{
+			"span",
+			[]*interlang.Node{
+				interlang.NewNode(interlang.FunctionDefine, &interlang.FunctionDefineField{
+					TType:  interlang.Integer,
+					Ident:  interlang.NewNode(interlang.Ident, &interlang.IdentField{S: "main"}).WithSpan(interlang.Span{Line: 1, Col: 5}),
+					Params: nil,
+					Block: interlang.NewNode(interlang.Block, &interlang.BlockField{Stmts: []*interlang.Node{
+						interlang.NewNode(interlang.Return, &interlang.ReturnField{Value: interlang.NewNode(interlang.Literal, &interlang.LiteralField{TType: interlang.Integer, I: 32})}).WithSpan(interlang.Span{Line: 2, Col: 5}),
+					}}).WithSpan(interlang.Span{Line: 1, Col: 12}),
+				}).WithSpan(interlang.Span{Line: 1, Col: 1}),
+			},
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  Integer,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}).WithSpan(Span{Line: 1, Col: 5}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(Return, &ReturnField{Value: NewNode(Literal, &LiteralField{TType: Integer, I: 32})}).WithSpan(Span{Line: 2, Col: 5}),
+					}}).WithSpan(Span{Line: 1, Col: 12}),
+				}).WithSpan(Span{Line: 1, Col: 1}),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -67,3 +122,59 @@ func TestConvertNodeFromInterLang(t *testing.T) {
 		})
 	}
 }
+
+// TestConvertLongChain 長い演算の連なりでも各項を一度だけ変換する
+func TestConvertLongChain(t *testing.T) {
+	chain := build.Id("x")
+	for i := 0; i < 200; i++ {
+		chain = build.Bin(build.Mul, chain, build.Id("x"))
+	}
+	got, err := ConvertNodeFromInterLang([]*interlang.Node{
+		build.Func("main", build.Int, build.Params(build.Param("x", build.Int)), build.Block(build.Return(chain))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	Walk(got[0], func(node *Node) bool {
+		if node.GetKind() == Binary {
+			n++
+		}
+		return true
+	})
+	if n != 200 {
+		t.Fatalf("expected 200 binary nodes, got %d", n)
+	}
+}
+
+// TestConvertConcurrent 付け替える名前の異なるプログラムを同時に変換しても状態が混ざらないことを確かめる
+func TestConvertConcurrent(t *testing.T) {
+	programs := []*interlang.Program{
+		{Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(build.Param("len", build.Int)), build.Block(build.Return(build.Id("len"))))}},
+		{Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(build.Param("n", build.Int)), build.Block(build.Return(build.Id("n"))))}},
+	}
+	expects := []string{"len_", "n"}
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			nodes, err := ConvertProgramFromInterLang(programs[i%2])
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			ret := nodes[0].GetField().(*FunctionDefineField).Block.GetField().(*BlockField).Stmts[0]
+			if got := ret.GetField().(*ReturnField).Value.GetField().(*IdentField).S; got != expects[i%2] {
+				errs[i] = fmt.Errorf("expected %s, got %s", expects[i%2], got)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
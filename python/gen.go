@@ -2,43 +2,83 @@ package python
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
 
-var nest int
+// loop 生成中のループ
+// pythonにはラベル付きのbreak、continueがないので
+// 外側のループを対象とするものはフラグを立てて内側のループを抜けていく
+type loop struct {
+	label string
+	// for文をwhile文に置き換えた時の更新式
+	// continueの前にも実行する必要がある
+	update *Node
+	// このループを対象としたフラグが必要になったか
+	breakFlag    bool
+	continueFlag bool
+	// このループを通り抜けて外側のループへ向かうbreak、continue
+	escapes []escape
+}
 
-type line struct {
-	s string // str
-	n int    // nest
+type escape struct {
+	target     *loop
+	isContinue bool
 }
 
-func newLine(s string, n int) *line {
-	return &line{s: s, n: n}
+// フラグの名前はループのラベルにこれらを前置したものになる
+const (
+	breakFlagPrefix    = "_break_"
+	continueFlagPrefix = "_continue_"
+)
+
+func (l *loop) breakFlagName() string {
+	return breakFlagPrefix + l.label
 }
 
-func genLine(lines []*line) string {
-	var s string
-	for _, l := range lines {
-		s += fmt.Sprintf("%s%s\n", strings.Repeat("    ", l.n), l.s)
+func (l *loop) continueFlagName() string {
+	return continueFlagPrefix + l.label
+}
+
+func (l *loop) escape(e escape) {
+	for _, other := range l.escapes {
+		if other == e {
+			return
+		}
 	}
-	return s
+	l.escapes = append(l.escapes, e)
 }
 
-func Gen(nodes []*Node) (string, error) {
-	lines, err := genProgram(nodes)
-	if err != nil {
-		return "", err
+// findLoop break、continueの対象のループを探す
+func (g *generator) findLoop(label string) (int, error) {
+	if len(g.loops) == 0 {
+		return 0, fmt.Errorf("break or continue outside of a loop")
+	}
+	if label == "" {
+		return len(g.loops) - 1, nil
+	}
+	for i := len(g.loops) - 1; i >= 0; i-- {
+		if g.loops[i].label == label {
+			return i, nil
+		}
 	}
-	code := genLine(lines)
-	code += "if __name__ == \"__main__\":\n    main()"
-	return code, nil
+	return 0, fmt.Errorf("unknown loop label: %s", label)
 }
 
-func genProgram(nodes []*Node) ([]*line, error) {
+type line struct {
+	s string // str
+	n int    // nest
+}
+
+func newLine(s string, n int) *line {
+	return &line{s: s, n: n}
+}
+
+func (g *generator) genProgram(nodes []*Node) ([]*line, error) {
 	var lines []*line
 	for _, node := range nodes {
-		l, err := genToplevel(node)
+		l, err := g.genToplevel(node)
 		if err != nil {
 			return nil, err
 		}
@@ -47,64 +87,117 @@ func genProgram(nodes []*Node) ([]*line, error) {
 	return lines, nil
 }
 
-func genToplevel(node *Node) ([]*line, error) {
+func (g *generator) genToplevel(node *Node) ([]*line, error) {
 	switch node.GetKind() {
+	case Import:
+		importField := node.GetField().(*ImportField)
+		return []*line{newLine(fmt.Sprintf("import %s", importField.Module), 0)}, nil
+	case Assign:
+		e, err := g.genExpr(node)
+		if err != nil {
+			return nil, err
+		}
+		return []*line{newLine(e, 0)}, nil
 	case FunctionDefine:
-		return genFunctionDefine(node)
+		return g.genFunctionDefine(node)
 	default:
-		panic("unhandled toplevel case") // TODO
+		return nil, fmt.Errorf("%v: unexpected toplevel node: %v", node.GetSpan(), node.GetKind())
 	}
-	return nil, nil
 }
 
-func genFunctionDefine(node *Node) ([]*line, error) {
+func (g *generator) genFunctionDefine(node *Node) ([]*line, error) {
 	field := node.GetField().(*FunctionDefineField)
 
 	identField := field.Ident.GetField().(*IdentField)
 	ident := identField.S
-	params, err := genFunctionDefineParams(field.Params)
+	params, err := g.genFunctionDefineParams(field.Params)
 	if err != nil {
 		return nil, err
 	}
-	block, err := genStmt(field.Block)
+	block, err := g.genBody(field.Block)
 	if err != nil {
 		return nil, err
 	}
 
+	var returns string
+	if hint := g.hintOf(field.TType); hint != "" {
+		returns = " -> " + hint
+	}
+
 	var lines []*line
-	lines = append(lines, newLine(fmt.Sprintf("def %s(%s):", ident, params), 0))
+	lines = append(lines, newLine(fmt.Sprintf("def %s(%s)%s:", ident, params, returns), 0))
 	lines = append(lines, block...)
 
 	return lines, nil
 }
 
-func genFunctionDefineParams(node *Node) (string, error) { // TODO
-	_ = node
-	return "", nil
+// hintOf 型ヒントを付けない場合は空文字列
+func (g *generator) hintOf(tt TType) string {
+	if !g.opts.TypeHints || tt == nil {
+		return ""
+	}
+	return typeHint(tt)
+}
+
+// genFunctionDefineParams 仮引数をカンマで区切って並べる
+// 既定値があればa=1の形にする
+func (g *generator) genFunctionDefineParams(node *Node) (string, error) {
+	if node == nil {
+		return "", nil
+	}
+	var params []string
+	for _, paramNode := range node.GetField().(*MultipleField).Values {
+		paramField := paramNode.GetField().(*ParamField)
+		param, err := g.genExpr(paramField.Ident)
+		if err != nil {
+			return "", err
+		}
+		hint := g.hintOf(paramField.TType)
+		if hint != "" {
+			param += ": " + hint
+		}
+		if paramField.Default != nil {
+			value, err := g.genExpr(paramField.Default)
+			if err != nil {
+				return "", err
+			}
+			// 型ヒントがある場合は=の前後に空白を置く(PEP 8)
+			if hint != "" {
+				param += " = " + value
+			} else {
+				param += "=" + value
+			}
+		}
+		params = append(params, param)
+	}
+	return strings.Join(params, ", "), nil
 }
 
-func genStmt(node *Node) ([]*line, error) {
+func (g *generator) genStmt(node *Node) ([]*line, error) {
 	switch node.GetKind() {
 	case Block:
 		var lines []*line
-		nest++
+		g.nest++
 		blockField := node.GetField().(*BlockField)
 		for _, stmtNode := range blockField.Stmts {
-			statements, err := genStmt(stmtNode)
+			statements, err := g.genStmt(stmtNode)
 			if err != nil {
 				return nil, err
 			}
 			lines = append(lines, statements...)
 		}
-		nest--
+		g.nest--
 		return lines, nil
 	case Return:
 		rvField := node.GetField().(*ReturnField)
-		rv, err := genExpr(rvField.Value)
+		if rvField.Value == nil {
+			return []*line{newLine("return", g.nest)}, nil
+		}
+		rv, err := g.genExpr(rvField.Value)
 		if err != nil {
 			return nil, err
 		}
-		return []*line{newLine(fmt.Sprintf("return %s", rv), nest)}, nil
+		return []*line{newLine(fmt.Sprintf("return %s", rv), g.nest)}, nil
 		//var values []string
 		//rvsField := rvField.Value.GetField().(*MultipleField)
 		//for _, valueNode := range rvsField.Values {
@@ -115,194 +208,653 @@ func genStmt(node *Node) ([]*line, error) {
 		//	values = append(values, v)
 		//}
 		//return []*line{newLine(fmt.Sprintf("return %s", strings.Join(values, ", ")), nest)}, nil
+	case Global:
+		globalField := node.GetField().(*GlobalField)
+		return []*line{newLine(fmt.Sprintf("global %s", strings.Join(globalField.Names, ", ")), g.nest)}, nil
 	case IfElse:
-		panic("unimplemented if case")
+		return g.genIfElse(node, "if")
 	case While:
-		panic("unimplemented while case")
+		return g.genWhile(node)
 	case For:
-		panic("unimplemented for case")
+		return g.genFor(node)
+	case ForIn:
+		return g.genForIn(node)
+	case Assign:
+		if assignField := node.GetField().(*AssignField); assignField.Value == nil {
+			return g.genDeclare(assignField)
+		}
+		e, err := g.genExpr(node)
+		if err != nil {
+			return nil, err
+		}
+		return []*line{newLine(e, g.nest)}, nil
+	case Break:
+		breakField := node.GetField().(*BreakField)
+		return g.genBreak(breakField.Label)
+	case Continue:
+		continueField := node.GetField().(*ContinueField)
+		return g.genContinue(continueField.Label)
 	default:
-		e, err := genExpr(node)
+		if name, args, ok := g.stdioCall(node); ok {
+			return g.genStdioStmt(node, name, args)
+		}
+		e, err := g.genExpr(node)
+		if err != nil {
+			return nil, err
+		}
+		return []*line{newLine(e, g.nest)}, nil
+	}
+}
+
+// genDeclare 値のない代入は宣言で、型ヒントを付ける場合だけ注釈にする
+//
+//	x: int
+func (g *generator) genDeclare(assignField *AssignField) ([]*line, error) {
+	hint := g.hintOf(assignField.TType)
+	if hint == "" {
+		return nil, nil
+	}
+	to, err := g.genExpr(assignField.To)
+	if err != nil {
+		return nil, err
+	}
+	return []*line{newLine(fmt.Sprintf("%s: %s", to, hint), g.nest)}, nil
+}
+
+// genBody ブロックを一段深く生成する
+// 中身が空ならpassを置く
+func (g *generator) genBody(node *Node) ([]*line, error) {
+	lines, err := g.genStmt(node)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		lines = append(lines, newLine("pass", g.nest+1))
+	}
+	return lines, nil
+}
+
+// genIfElse else節にif文があればelifに繋げる
+func (g *generator) genIfElse(node *Node, keyword string) ([]*line, error) {
+	ifElseField := node.GetField().(*IfElseField)
+	cond, err := g.genExpr(ifElseField.Cond)
+	if err != nil {
+		return nil, err
+	}
+	ifBlock, err := g.genBody(ifElseField.IfBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []*line
+	lines = append(lines, newLine(fmt.Sprintf("%s %s:", keyword, cond), g.nest))
+	lines = append(lines, ifBlock...)
+	if ifElseField.ElseBlock == nil {
+		return lines, nil
+	}
+	if ifElseField.ElseBlock.GetKind() == IfElse {
+		elif, err := g.genIfElse(ifElseField.ElseBlock, "elif")
+		if err != nil {
+			return nil, err
+		}
+		return append(lines, elif...), nil
+	}
+	elseBlock, err := g.genBody(ifElseField.ElseBlock)
+	if err != nil {
+		return nil, err
+	}
+	lines = append(lines, newLine("else:", g.nest))
+	return append(lines, elseBlock...), nil
+}
+
+// genFor Cのfor文はpythonに対応するものがないのでwhile文に置き換える
+//
+//	init
+//	while cond:
+//	    block
+//	    loop
+func (g *generator) genFor(node *Node) ([]*line, error) {
+	forField := node.GetField().(*ForField)
+
+	var lines []*line
+	if forField.Init != nil {
+		init, err := g.genExpr(forField.Init)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, newLine(init, g.nest))
+	}
+	cond, err := g.genCond(forField.Cond)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loop{label: forField.Label, update: forField.Loop}
+	g.loops = append(g.loops, l)
+	block, err := g.genStmt(forField.Block)
+	if err != nil {
+		return nil, err
+	}
+	if forField.Loop != nil {
+		g.nest++
+		update, err := g.genExpr(forField.Loop)
+		g.nest--
+		if err != nil {
+			return nil, err
+		}
+		block = append(block, newLine(update, g.nest+1))
+	}
+	g.loops = g.loops[:len(g.loops)-1]
+
+	loopLines, err := g.genLoop(l, fmt.Sprintf("while %s:", cond), block)
+	if err != nil {
+		return nil, err
+	}
+	return append(lines, loopLines...), nil
+}
+
+// genWhile while文
+func (g *generator) genWhile(node *Node) ([]*line, error) {
+	whileField := node.GetField().(*WhileField)
+	cond, err := g.genCond(whileField.Cond)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loop{label: whileField.Label}
+	g.loops = append(g.loops, l)
+	block, err := g.genStmt(whileField.Block)
+	if err != nil {
+		return nil, err
+	}
+	g.loops = g.loops[:len(g.loops)-1]
+
+	return g.genLoop(l, fmt.Sprintf("while %s:", cond), block)
+}
+
+// genForIn for target in iter:
+func (g *generator) genForIn(node *Node) ([]*line, error) {
+	forInField := node.GetField().(*ForInField)
+	target, err := g.genExpr(forInField.Target)
+	if err != nil {
+		return nil, err
+	}
+	iter, err := g.genExpr(forInField.Iter)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &loop{label: forInField.Label}
+	g.loops = append(g.loops, l)
+	block, err := g.genStmt(forInField.Block)
+	if err != nil {
+		return nil, err
+	}
+	g.loops = g.loops[:len(g.loops)-1]
+
+	return g.genLoop(l, fmt.Sprintf("for %s in %s:", target, iter), block)
+}
+
+// genCond ループの条件
+// 省略されているか、常に真となる定数ならTrueにする
+func (g *generator) genCond(node *Node) (string, error) {
+	if node == nil {
+		return "True", nil
+	}
+	if node.GetKind() == Literal {
+		literalField := node.GetField().(*LiteralField)
+		switch literalField.GetTType() {
+		case Integer, Bool:
+			if literalField.I != 0 {
+				return "True", nil
+			}
+		}
+	}
+	return g.genExpr(node)
+}
+
+// genLoop headerから始まるループを組み立て、フラグの初期化と外側へ抜けるための判定を付け加える
+func (g *generator) genLoop(l *loop, header string, block []*line) ([]*line, error) {
+	var lines []*line
+	if l.breakFlag {
+		lines = append(lines, newLine(fmt.Sprintf("%s = False", l.breakFlagName()), g.nest))
+	}
+	lines = append(lines, newLine(header, g.nest))
+	if l.continueFlag {
+		lines = append(lines, newLine(fmt.Sprintf("%s = False", l.continueFlagName()), g.nest+1))
+	}
+	if len(block) == 0 {
+		block = append(block, newLine("pass", g.nest+1))
+	}
+	lines = append(lines, block...)
+
+	// このループを抜けた直後に、さらに外側へ向かうかを判定する
+	for _, e := range l.escapes {
+		var outer *loop
+		if len(g.loops) != 0 {
+			outer = g.loops[len(g.loops)-1]
+		}
+		if e.isContinue {
+			lines = append(lines, newLine(fmt.Sprintf("if %s:", e.target.continueFlagName()), g.nest))
+		} else {
+			lines = append(lines, newLine(fmt.Sprintf("if %s:", e.target.breakFlagName()), g.nest))
+		}
+		if e.target != outer {
+			outer.escape(e)
+			lines = append(lines, newLine("break", g.nest+1))
+			continue
+		}
+		if e.isContinue {
+			update, err := g.genUpdate(outer, g.nest+1)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, update...)
+			lines = append(lines, newLine("continue", g.nest+1))
+		} else {
+			lines = append(lines, newLine("break", g.nest+1))
+		}
+	}
+	return lines, nil
+}
+
+// genUpdate for文から置き換えたループの更新式
+func (g *generator) genUpdate(l *loop, n int) ([]*line, error) {
+	if l.update == nil {
+		return nil, nil
+	}
+	update, err := g.genExpr(l.update)
+	if err != nil {
+		return nil, err
+	}
+	return []*line{newLine(update, n)}, nil
+}
+
+func (g *generator) genBreak(label string) ([]*line, error) {
+	i, err := g.findLoop(label)
+	if err != nil {
+		return nil, err
+	}
+	inner := g.loops[len(g.loops)-1]
+	target := g.loops[i]
+	if target == inner {
+		return []*line{newLine("break", g.nest)}, nil
+	}
+	target.breakFlag = true
+	inner.escape(escape{target: target})
+	return []*line{
+		newLine(fmt.Sprintf("%s = True", target.breakFlagName()), g.nest),
+		newLine("break", g.nest),
+	}, nil
+}
+
+func (g *generator) genContinue(label string) ([]*line, error) {
+	i, err := g.findLoop(label)
+	if err != nil {
+		return nil, err
+	}
+	inner := g.loops[len(g.loops)-1]
+	target := g.loops[i]
+	if target == inner {
+		update, err := g.genUpdate(target, g.nest)
 		if err != nil {
 			return nil, err
 		}
-		return []*line{newLine(e, nest)}, nil
+		return append(update, newLine("continue", g.nest)), nil
 	}
-	return nil, nil
+	target.continueFlag = true
+	inner.escape(escape{target: target, isContinue: true})
+	return []*line{
+		newLine(fmt.Sprintf("%s = True", target.continueFlagName()), g.nest),
+		newLine("break", g.nest),
+	}, nil
 }
 
-func genExpr(node *Node) (string, error) {
-	return genAssign(node)
+func (g *generator) genExpr(node *Node) (string, error) {
+	return g.genAssign(node)
 }
 
-func genAssign(node *Node) (string, error) {
+func (g *generator) genAssign(node *Node) (string, error) {
 	switch node.GetKind() {
 	case Assign:
 		assignField := node.GetField().(*AssignField)
-		to, err := genExpr(assignField.To)
+		to, err := g.genExpr(assignField.To)
 		if err != nil {
 			return "", err
 		}
-		val, err := genExpr(assignField.Value)
+		val, err := g.genExpr(assignField.Value)
 		if err != nil {
 			return "", err
 		}
+		if hint := g.hintOf(assignField.TType); hint != "" {
+			return fmt.Sprintf("%s: %s = %s", to, hint, val), nil
+		}
 		return fmt.Sprintf("%s = %s", to, val), nil
 	default:
-		return genAndor(node)
+		return g.genOr(node)
 	}
 }
 
-func genAndor(node *Node) (string, error) {
+// 式はpythonの演算子の優先順位の低い順に生成する
+// 各段は自分より弱く結びつく子ノードを括弧で囲む(genPrimaryまで降りてきたら括弧を付ける)
+//
+//	or < and < not < 比較 < | < ^ < & < シフト < +, - < *, /, % < 単項演算
+//
+// 二項演算は左結合なので、右辺に同じ優先順位の演算があれば括弧で囲む
+
+func (g *generator) genOr(node *Node) (string, error) {
 	if node.GetKind() != Binary {
-		return genEquality(node)
+		return g.genAnd(node)
 	}
 	binaryField := node.GetField().(*BinaryField)
-	lhs, err := genAndor(binaryField.LHS)
-	if err != nil {
-		return "", err
-	}
-	rhs, err := genAndor(binaryField.RHS)
-	if err != nil {
-		return "", err
-	}
 	switch binaryField.Operation {
-	case And:
-		return fmt.Sprintf("%s and %s", lhs, rhs), nil
 	case Or:
-		return fmt.Sprintf("%s or %s", lhs, rhs), nil
+		return g.genBinary(binaryField, "or", g.genOr, g.genAnd)
 	default:
-		panic("unimplemented binary op")
+		return g.genAnd(node)
 	}
-	return "", nil
 }
 
-func genEquality(node *Node) (string, error) {
+func (g *generator) genAnd(node *Node) (string, error) {
 	if node.GetKind() != Binary {
-		return genRelational(node)
+		return g.genNot(node)
 	}
-
 	binaryField := node.GetField().(*BinaryField)
-	lhs, err := genEquality(binaryField.LHS)
-	if err != nil {
-		return "", err
+	switch binaryField.Operation {
+	case And:
+		return g.genBinary(binaryField, "and", g.genAnd, g.genNot)
+	default:
+		return g.genNot(node)
+	}
+}
+
+func (g *generator) genNot(node *Node) (string, error) {
+	if node.GetKind() != Not {
+		return g.genComparison(node)
 	}
-	rhs, err := genEquality(binaryField.RHS)
+	notField := node.GetField().(*NotField)
+	value, err := g.genNot(notField.Value)
 	if err != nil {
 		return "", err
 	}
+	return fmt.Sprintf("not %s", value), nil
+}
+
+// genComparison pythonでは等価演算と関係演算が同じ優先順位で、a < b < cのように連鎖する
+// 連鎖として読まれないよう、両辺に比較があれば括弧で囲む
+func (g *generator) genComparison(node *Node) (string, error) {
+	if node.GetKind() != Binary {
+		return g.genBitOr(node)
+	}
+	binaryField := node.GetField().(*BinaryField)
+	var op string
 	switch binaryField.Operation {
 	case Eq:
-		return fmt.Sprintf("%s == %s", lhs, rhs), nil
+		op = "=="
 	case Ne:
-		return fmt.Sprintf("%s != %s", lhs, rhs), nil
+		op = "!="
+	case Lt:
+		op = "<"
+	case Le:
+		op = "<="
+	case Gt:
+		op = ">"
+	case Ge:
+		op = ">="
 	default:
-		panic("unimplemented binary op")
+		return g.genBitOr(node)
 	}
+	return g.genBinary(binaryField, op, g.genBitOr, g.genBitOr)
+}
 
-	return "", nil
+func (g *generator) genBitOr(node *Node) (string, error) {
+	if node.GetKind() != Binary {
+		return g.genBitXor(node)
+	}
+	binaryField := node.GetField().(*BinaryField)
+	switch binaryField.Operation {
+	case BitOr:
+		return g.genBinary(binaryField, "|", g.genBitOr, g.genBitXor)
+	default:
+		return g.genBitXor(node)
+	}
 }
 
-func genRelational(node *Node) (string, error) {
+func (g *generator) genBitXor(node *Node) (string, error) {
 	if node.GetKind() != Binary {
-		return genAdd(node)
+		return g.genBitAnd(node)
 	}
 	binaryField := node.GetField().(*BinaryField)
-	lhs, err := genRelational(binaryField.LHS)
-	if err != nil {
-		return "", err
+	switch binaryField.Operation {
+	case BitXor:
+		return g.genBinary(binaryField, "^", g.genBitXor, g.genBitAnd)
+	default:
+		return g.genBitAnd(node)
 	}
-	rhs, err := genRelational(binaryField.RHS)
-	if err != nil {
-		return "", err
+}
+
+func (g *generator) genBitAnd(node *Node) (string, error) {
+	if node.GetKind() != Binary {
+		return g.genShift(node)
 	}
+	binaryField := node.GetField().(*BinaryField)
 	switch binaryField.Operation {
-	case Lt:
-		return fmt.Sprintf("%s < %s", lhs, rhs), err
-	case Le:
-		return fmt.Sprintf("%s <= %s", lhs, rhs), err
-	case Gt:
-		return fmt.Sprintf("%s > %s", lhs, rhs), err
-	case Ge:
-		return fmt.Sprintf("%s >= %s", lhs, rhs), err
+	case BitAnd:
+		return g.genBinary(binaryField, "&", g.genBitAnd, g.genShift)
 	default:
-		panic("unimplemented binary op")
+		return g.genShift(node)
 	}
-	return "", nil
 }
 
-func genAdd(node *Node) (string, error) {
-	switch node.GetKind() {
-	case Binary:
-		binaryField := node.GetField().(*BinaryField)
-		lhs, err := genAdd(binaryField.LHS)
-		if err != nil {
-			return "", err
-		}
-		rhs, err := genAdd(binaryField.RHS)
-		if err != nil {
-			return "", err
-		}
-		switch binaryField.Operation {
-		case Add:
-			return fmt.Sprintf("%s + %s", lhs, rhs), nil
-		case Sub:
-			return fmt.Sprintf("%s - %s", lhs, rhs), nil
-		default:
-			panic("unimplemented binary op")
-		}
+// genShift pythonの整数は上限がなく負の数も2の補数として振る舞うので
+// intに収まる範囲ではCの符号付き整数と同じ結果になる(右シフトは算術シフト)
+func (g *generator) genShift(node *Node) (string, error) {
+	if node.GetKind() != Binary {
+		return g.genAdd(node)
+	}
+	binaryField := node.GetField().(*BinaryField)
+	var op string
+	switch binaryField.Operation {
+	case Shl:
+		op = "<<"
+	case Shr:
+		op = ">>"
 	default:
-		return genMul(node)
+		return g.genAdd(node)
 	}
-	return "", nil
+	return g.genBinary(binaryField, op, g.genShift, g.genAdd)
 }
 
-func genMul(node *Node) (string, error) {
-	switch node.GetKind() {
-	case Binary:
-		binaryField := node.GetField().(*BinaryField)
-		lhs, err := genMul(binaryField.LHS)
-		if err != nil {
-			return "", err
-		}
-		rhs, err := genMul(binaryField.RHS)
-		if err != nil {
-			return "", err
+func (g *generator) genAdd(node *Node) (string, error) {
+	if node.GetKind() != Binary {
+		return g.genMul(node)
+	}
+	binaryField := node.GetField().(*BinaryField)
+	var op string
+	switch binaryField.Operation {
+	case Add:
+		op = "+"
+	case Sub:
+		op = "-"
+	default:
+		return g.genMul(node)
+	}
+	return g.genBinary(binaryField, op, g.genAdd, g.genMul)
+}
+
+func (g *generator) genMul(node *Node) (string, error) {
+	if node.GetKind() != Binary {
+		return g.genUnary(node)
+	}
+	binaryField := node.GetField().(*BinaryField)
+	switch binaryField.Operation {
+	case Mul:
+		return g.genBinary(binaryField, "*", g.genMul, g.genUnary)
+	case Div:
+		if floatDivision(binaryField) {
+			return g.genBinary(binaryField, "/", g.genMul, g.genUnary)
 		}
-		switch binaryField.Operation {
-		case Mul:
-			return fmt.Sprintf("%s * %s", lhs, rhs), nil
-		case Div:
-			return fmt.Sprintf("%s / %s", lhs, rhs), nil
-		case Mod:
-			return fmt.Sprintf("%s %% %s", lhs, rhs), nil
-		default:
-			panic("unimplemented binary op")
+		return g.genIntDivision(binaryField, "//", "_cdiv")
+	case Mod:
+		if floatDivision(binaryField) {
+			return "", fmt.Errorf("%v: %% on floating point operands", node.GetSpan())
 		}
+		return g.genIntDivision(binaryField, "%", "_cmod")
 	default:
-		return genUnary(node)
+		return g.genUnary(node)
 	}
-	return "", nil
 }
 
-func genUnary(node *Node) (string, error) {
-	return genPrimary(node)
+// exprType 式の型
+// 分からなければnilを返す
+func exprType(node *Node) TType {
+	switch field := node.GetField().(type) {
+	case *IdentField:
+		return field.TType
+	case interface{ GetTType() TType }:
+		return field.GetTType()
+	default:
+		return nil
+	}
+}
+
+// floatDivision 除算と剰余が浮動小数点数の演算か
+// Cと同じく被演算子のどちらかが浮動小数点数なら浮動小数点数、両方が整数なら整数の演算とする
+// 被演算子の型が分からなければ式の型で決める
+func floatDivision(binaryField *BinaryField) bool {
+	lhs, rhs := exprType(binaryField.LHS), exprType(binaryField.RHS)
+	if lhs == Float || rhs == Float {
+		return true
+	}
+	if isInteger(lhs) && isInteger(rhs) {
+		return false
+	}
+	return binaryField.GetTType() == Float
 }
 
-func genPrimary(node *Node) (string, error) {
+func isInteger(tt TType) bool {
+	return tt == Integer || tt == Bool
+}
+
+// needsDivisionHelper 除算と剰余を補助関数の呼び出しにするか
+func needsDivisionHelper(binaryField *BinaryField) bool {
+	switch binaryField.Operation {
+	case Div, Mod:
+		return !floatDivision(binaryField) && !(nonNegative(binaryField.LHS) && nonNegative(binaryField.RHS))
+	default:
+		return false
+	}
+}
+
+// genIntDivision 整数の除算と剰余
+// pythonの//と%は負の無限大に向かって、Cは0に向かって切り捨てるので、
+// 結果が一致するのは両辺が負でないと分かる場合だけ。その場合だけ演算子を使い、それ以外は補助関数を呼ぶ
+func (g *generator) genIntDivision(binaryField *BinaryField, op string, helper string) (string, error) {
+	if !needsDivisionHelper(binaryField) {
+		return g.genBinary(binaryField, op, g.genMul, g.genUnary)
+	}
+	lhs, err := g.genExpr(binaryField.LHS)
+	if err != nil {
+		return "", err
+	}
+	rhs, err := g.genExpr(binaryField.RHS)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(%s, %s)", g.useHelper(helper), lhs, rhs), nil
+}
+
+func (g *generator) genUnary(node *Node) (string, error) {
+	if node.GetKind() != Unary {
+		return g.genPrimary(node)
+	}
+	unaryField := node.GetField().(*UnaryField)
+	value, err := g.genUnary(unaryField.Value)
+	if err != nil {
+		return "", err
+	}
+	switch unaryField.Operation {
+	case Neg:
+		return fmt.Sprintf("-%s", value), nil
+	case Plus:
+		return fmt.Sprintf("+%s", value), nil
+	case BitNot:
+		return fmt.Sprintf("~%s", value), nil
+	case Addr:
+		return "", fmt.Errorf("%v: address-of is only supported in scanf arguments", node.GetSpan())
+	default:
+		panic("unimplemented unary op")
+	}
+}
+
+// genBinary 左辺をgenLHS、右辺をgenRHSで生成して演算子で繋ぐ
+func (g *generator) genBinary(binaryField *BinaryField, op string, genLHS, genRHS func(*Node) (string, error)) (string, error) {
+	lhs, err := genLHS(binaryField.LHS)
+	if err != nil {
+		return "", err
+	}
+	rhs, err := genRHS(binaryField.RHS)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %s", lhs, op, rhs), nil
+}
+
+func (g *generator) genPrimary(node *Node) (string, error) {
 	switch node.GetKind() {
 	case Ident:
 		identField := node.GetField().(*IdentField)
 		ident := identField.S
 		return ident, nil
 	case Call:
-		return genCall(node)
+		return g.genCall(node)
 	case Literal:
 		return genLiteral(node)
+	case Assign:
+		// 式の中の代入は代入式にする
+		assignField := node.GetField().(*AssignField)
+		if assignField.To.GetKind() != Ident {
+			return "", fmt.Errorf("%v: assignment expression requires a variable", node.GetSpan())
+		}
+		if err := g.require(node, 3, 8, "assignment expression"); err != nil {
+			return "", err
+		}
+		value, err := g.genOr(assignField.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(%s := %s)", assignField.To.GetField().(*IdentField).S, value), nil
+	case Binary, Unary, Not:
+		// 補助関数の呼び出しは括弧で囲まなくてよい
+		if binaryField, ok := node.GetField().(*BinaryField); ok && needsDivisionHelper(binaryField) {
+			return g.genMul(node)
+		}
+		// 外側の演算より弱く結びつく演算
+		e, err := g.genOr(node)
+		if err != nil {
+			return "", err
+		}
+		return "(" + e + ")", nil
 	default:
 		panic("unexpected primary")
 	}
-	return "", nil
+}
+
+// genFloat pythonのfloatとして読める表記
+func genFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return `float("inf")`
+	case math.IsInf(f, -1):
+		return `float("-inf")`
+	case math.IsNaN(f):
+		return `float("nan")`
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
 }
 
 func genLiteral(node *Node) (string, error) {
@@ -311,64 +863,42 @@ func genLiteral(node *Node) (string, error) {
 	case Integer:
 		return strconv.Itoa(literalField.I), nil
 	case String:
-		return literalField.S, nil
+		return Quote(literalField.S), nil
 	case Bool:
-		panic("unimplemented literal")
+		if literalField.I != 0 {
+			return "True", nil
+		}
+		return "False", nil
+	case Null:
+		return "None", nil
+	case Float:
+		return genFloat(literalField.F), nil
 	default:
-		panic("unimplemented literal")
+		return "", fmt.Errorf("%v: unsupported literal: %v", node.GetSpan(), literalField.GetTType())
 	}
-	return "", nil
 }
 
-func genCall(node *Node) (string, error) {
+func (g *generator) genCall(node *Node) (string, error) {
 	callField := node.GetField().(*CallField)
 	identField := callField.Ident.GetField().(*IdentField)
 	ident := identField.S
 	argsField := callField.Args.GetField().(*MultipleField)
 	args := argsField.Values
 
-	var code string
-	if ident == "printf" {
-		var arguments string
-		for i, arg := range args[1:] {
-			a, err := genExpr(arg)
-			if err != nil {
-				return "", err
-			}
-			if i != 0 {
-				arguments += ", "
-			}
-			arguments += a
-		}
+	if g.stdioFunction(ident) {
+		return g.genStdioCall(node, ident, args)
+	}
 
-		f, err := genExpr(args[0])
+	var arguments string
+	for i, arg := range args {
+		a, err := g.genExpr(arg)
 		if err != nil {
 			return "", err
 		}
-		if arguments == "" {
-			code = fmt.Sprintf("print(%#v)", f)
-		} else {
-			code = fmt.Sprintf("print(%#v.format(%s))", formatting(f), arguments)
-		}
-	} else {
-		var arguments string
-		for i, arg := range args {
-			a, err := genExpr(arg)
-			if err != nil {
-				return "", err
-			}
-			if i != 0 {
-				arguments += ", "
-			}
-			arguments += a
+		if i != 0 {
+			arguments += ", "
 		}
-		code = fmt.Sprintf("%s(%s)", ident, arguments)
+		arguments += a
 	}
-	return code, nil
-}
-
-func formatting(s string) string {
-	s = strings.ReplaceAll(s, "%d", "{}")
-	s = strings.ReplaceAll(s, "%s", "{}")
-	return s
+	return fmt.Sprintf("%s(%s)", ident, arguments), nil
 }
@@ -1,7 +1,13 @@
 package python
 
 import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"fmt"
 	"github.com/google/go-cmp/cmp"
+	"math/rand"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -25,6 +31,172 @@ func TestGen(t *testing.T) {
 			},
 			"def main():\n    return 32\nif __name__ == \"__main__\":\n    main()",
 		},
+		{
+			"void return",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  Null,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(Return, &ReturnField{}),
+					}}),
+				}),
+			},
+			"def main():\n    return\nif __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"bitwise",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  nil,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(Return, &ReturnField{Value: NewNode(Binary, &BinaryField{
+							TType:     Integer,
+							Operation: BitXor,
+							LHS: NewNode(Binary, &BinaryField{
+								TType:     Integer,
+								Operation: Shr,
+								LHS:       NewNode(Unary, &UnaryField{TType: Integer, Operation: Neg, Value: NewNode(Ident, &IdentField{S: "x"})}),
+								RHS:       NewNode(Literal, &LiteralField{TType: Integer, I: 2}),
+							}),
+							RHS: NewNode(Unary, &UnaryField{TType: Integer, Operation: BitNot, Value: NewNode(Ident, &IdentField{S: "y"})}),
+						})}),
+					}}),
+				}),
+			},
+			"def main():\n    return -x >> 2 ^ ~y\nif __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"for continue",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  nil,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(For, &ForField{
+							Init: NewNode(Assign, &AssignField{To: NewNode(Ident, &IdentField{S: "i"}), Value: NewNode(Literal, &LiteralField{TType: Integer, I: 0})}),
+							Cond: NewNode(Binary, &BinaryField{TType: Bool, Operation: Lt, LHS: NewNode(Ident, &IdentField{S: "i"}), RHS: NewNode(Literal, &LiteralField{TType: Integer, I: 10})}),
+							Loop: NewNode(Assign, &AssignField{To: NewNode(Ident, &IdentField{S: "i"}), Value: NewNode(Binary, &BinaryField{TType: Integer, Operation: Add, LHS: NewNode(Ident, &IdentField{S: "i"}), RHS: NewNode(Literal, &LiteralField{TType: Integer, I: 1})})}),
+							Block: NewNode(Block, &BlockField{Stmts: []*Node{
+								NewNode(IfElse, &IfElseField{
+									Cond:      NewNode(Ident, &IdentField{S: "i"}),
+									IfBlock:   NewNode(Block, &BlockField{Stmts: []*Node{NewNode(Continue, &ContinueField{})}}),
+									ElseBlock: NewNode(Block, &BlockField{Stmts: []*Node{NewNode(Break, &BreakField{})}}),
+								}),
+							}}),
+						}),
+					}}),
+				}),
+			},
+			"def main():\n" +
+				"    i = 0\n" +
+				"    while i < 10:\n" +
+				"        if i:\n" +
+				"            i = i + 1\n" +
+				"            continue\n" +
+				"        else:\n" +
+				"            break\n" +
+				"        i = i + 1\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"labeled break",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  nil,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(For, &ForField{
+							Label: "outer",
+							Block: NewNode(Block, &BlockField{Stmts: []*Node{
+								NewNode(For, &ForField{
+									Block: NewNode(Block, &BlockField{Stmts: []*Node{
+										NewNode(Break, &BreakField{Label: "outer"}),
+									}}),
+								}),
+							}}),
+						}),
+					}}),
+				}),
+			},
+			"def main():\n" +
+				"    _break_outer = False\n" +
+				"    while True:\n" +
+				"        while True:\n" +
+				"            _break_outer = True\n" +
+				"            break\n" +
+				"        if _break_outer:\n" +
+				"            break\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"while",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  nil,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(While, &WhileField{
+							Cond: NewNode(Literal, &LiteralField{TType: Integer, I: 1}),
+							Block: NewNode(Block, &BlockField{Stmts: []*Node{
+								NewNode(While, &WhileField{
+									Cond:  NewNode(Binary, &BinaryField{TType: Bool, Operation: Lt, LHS: NewNode(Ident, &IdentField{S: "i"}), RHS: NewNode(Literal, &LiteralField{TType: Integer, I: 10})}),
+									Block: NewNode(Block, &BlockField{}),
+								}),
+								NewNode(Break, &BreakField{}),
+							}}),
+						}),
+						NewNode(Return, &ReturnField{Value: NewNode(Literal, &LiteralField{TType: Integer, I: 0})}),
+					}}),
+				}),
+			},
+			"def main():\n" +
+				"    while True:\n" +
+				"        while i < 10:\n" +
+				"            pass\n" +
+				"        break\n" +
+				"    return 0\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"labeled while continue",
+			[]*Node{
+				NewNode(FunctionDefine, &FunctionDefineField{
+					TType:  nil,
+					Ident:  NewNode(Ident, &IdentField{S: "main"}),
+					Params: nil,
+					Block: NewNode(Block, &BlockField{Stmts: []*Node{
+						NewNode(While, &WhileField{
+							Cond:  NewNode(Ident, &IdentField{S: "x"}),
+							Label: "outer",
+							Block: NewNode(Block, &BlockField{Stmts: []*Node{
+								NewNode(While, &WhileField{
+									Cond: NewNode(Literal, &LiteralField{TType: Bool, I: 1}),
+									Block: NewNode(Block, &BlockField{Stmts: []*Node{
+										NewNode(Continue, &ContinueField{Label: "outer"}),
+									}}),
+								}),
+							}}),
+						}),
+					}}),
+				}),
+			},
+			"def main():\n" +
+				"    while x:\n" +
+				"        _continue_outer = False\n" +
+				"        while True:\n" +
+				"            _continue_outer = True\n" +
+				"            break\n" +
+				"        if _continue_outer:\n" +
+				"            continue\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
 	}
 
 	for _, tt := range tests {
@@ -39,3 +211,375 @@ func TestGen(t *testing.T) {
 		})
 	}
 }
+
+func TestGenWithTypeHints(t *testing.T) {
+	p := &interlang.Program{
+		Globals: []*interlang.Node{
+			build.Declare("count", build.Int),
+			build.Declare("name", build.String),
+		},
+		Funcs: []*interlang.Node{
+			build.Func("add", build.Int, build.Params(build.Param("a", build.Int), build.ParamDefault("b", build.Int, build.IntLit(1))), build.Block(
+				build.Define("c", build.Int, build.Bin(build.Add, build.Id("a"), build.Id("b"))),
+				build.Return(build.Id("c")),
+			)),
+			build.Func("fill", interlang.Null, build.Params(
+				build.Param("xs", interlang.TPointer{Elem: build.Int}),
+				build.Param("p", interlang.TStruct{Name: "point"}),
+			), build.Block()),
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Declare("x", build.Int),
+				build.Assign(build.Id("x"), build.Call("add", build.IntLit(1))),
+				build.Return(build.Id("x")),
+			)),
+		},
+	}
+	nodes, err := ConvertProgramFromInterLang(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GenWithTypeHints(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "count: int = 0\n" +
+		"name = None\n" +
+		"def add(a: int, b: int = 1) -> int:\n" +
+		"    c: int = a + b\n" +
+		"    return c\n" +
+		"def fill(xs: list[int], p: \"point\") -> None:\n" +
+		"    pass\n" +
+		"def main() -> int:\n" +
+		"    x: int\n" +
+		"    x = add(1)\n" +
+		"    return x\n" +
+		"if __name__ == \"__main__\":\n    main()"
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+
+	// 型ヒントなしで生成しても型は付かない
+	got, err = Gen(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, ": int") || strings.Contains(got, "->") || !strings.Contains(got, "    x = add(1)\n") {
+		t.Fatalf("unexpected type hints:\n%s", got)
+	}
+}
+
+func TestGenParenthesize(t *testing.T) {
+	id := func(s string) *Node { return NewNode(Ident, &IdentField{S: s}) }
+	bin := func(op Operation, lhs, rhs *Node) *Node {
+		return NewNode(Binary, &BinaryField{Operation: op, LHS: lhs, RHS: rhs})
+	}
+	tests := []struct {
+		name   string
+		in     *Node
+		expect string
+	}{
+		{"lower precedence operand", bin(Mul, bin(Add, id("a"), id("b")), id("c")), "(a + b) * c"},
+		{"higher precedence operand", bin(Add, id("a"), bin(Mul, id("b"), id("c"))), "a + b * c"},
+		{"left associative", bin(Sub, bin(Sub, id("a"), id("b")), id("c")), "a - b - c"},
+		{"right operand", bin(Sub, id("a"), bin(Sub, id("b"), id("c"))), "a - (b - c)"},
+		{"chained comparison", bin(Eq, bin(Lt, id("a"), id("b")), id("c")), "(a < b) == c"},
+		{"and in or", bin(Or, id("a"), bin(And, id("b"), id("c"))), "a or b and c"},
+		{"or in and", bin(And, bin(Or, id("a"), id("b")), id("c")), "(a or b) and c"},
+		{"not in comparison", bin(Eq, NewNode(Not, &NotField{Value: id("a")}), id("b")), "(not a) == b"},
+		{"unary of binary", NewNode(Unary, &UnaryField{Operation: Neg, Value: bin(Add, id("a"), id("b"))}), "-(a + b)"},
+		{"bitand in comparison", bin(Eq, bin(BitAnd, id("a"), id("b")), id("c")), "a & b == c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestGenerator().genExpr(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+// newTestGenerator 式を単体で生成するための状態
+func newTestGenerator() *generator {
+	return &generator{usedHelpers: map[string]bool{}, usedModules: map[string]bool{}, functions: map[string]bool{}}
+}
+
+// TestGenParenthesizeRoundTrip 無作為に作った式を生成し、読み直すと同じ木になることを確かめる
+func TestGenParenthesizeRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	binaryOps := []Operation{Add, Sub, Mul, Div, Mod, And, Or, Eq, Ne, Lt, Le, Gt, Ge, BitAnd, BitOr, BitXor, Shl, Shr}
+	unaryOps := []Operation{Neg, Plus, BitNot}
+	var random func(depth int) *Node
+	random = func(depth int) *Node {
+		if depth == 0 || r.Intn(4) == 0 {
+			if r.Intn(2) == 0 {
+				return NewNode(Ident, &IdentField{S: string(rune('a' + r.Intn(3)))})
+			}
+			return NewNode(Literal, &LiteralField{TType: Integer, I: r.Intn(10)})
+		}
+		switch r.Intn(6) {
+		case 0:
+			return NewNode(Not, &NotField{Value: random(depth - 1)})
+		case 1:
+			return NewNode(Unary, &UnaryField{Operation: unaryOps[r.Intn(len(unaryOps))], Value: random(depth - 1)})
+		default:
+			return NewNode(Binary, &BinaryField{Operation: binaryOps[r.Intn(len(binaryOps))], LHS: random(depth - 1), RHS: random(depth - 1)})
+		}
+	}
+
+	for i := 0; i < 2000; i++ {
+		tree := random(5)
+		code, err := newTestGenerator().genExpr(tree)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := parseExpr(code)
+		if err != nil {
+			t.Fatalf("%s: %v", code, err)
+		}
+		if !Equal(tree, got, EqualOptions{IgnoreSpan: true, IgnoreTType: true}) {
+			t.Fatalf("%s is parsed as a different tree", code)
+		}
+	}
+}
+
+// parseExpr 生成した式をpythonの文法で読む
+// 比較の連鎖は元の木に戻せないのでエラーにする
+func parseExpr(code string) (*Node, error) {
+	p := &exprParser{tokens: strings.Fields(tokenSpacer.Replace(code))}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return node, nil
+}
+
+var tokenSpacer = strings.NewReplacer("(", " ( ", ")", " ) ", ",", " , ", "-", " - ", "+", " + ", "~", " ~ ")
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// binary 左結合の二項演算の一段
+func (p *exprParser) binary(ops map[string]Operation, operand func() (*Node, error)) (*Node, error) {
+	lhs, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := ops[p.peek()]
+		if !ok {
+			return lhs, nil
+		}
+		p.next()
+		rhs, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		lhs = NewNode(Binary, &BinaryField{Operation: op, LHS: lhs, RHS: rhs})
+	}
+}
+
+func (p *exprParser) or() (*Node, error) {
+	return p.binary(map[string]Operation{"or": Or}, p.and)
+}
+
+func (p *exprParser) and() (*Node, error) {
+	return p.binary(map[string]Operation{"and": And}, p.not)
+}
+
+func (p *exprParser) not() (*Node, error) {
+	if p.peek() != "not" {
+		return p.comparison()
+	}
+	p.next()
+	value, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	return NewNode(Not, &NotField{Value: value}), nil
+}
+
+func (p *exprParser) comparison() (*Node, error) {
+	ops := map[string]Operation{"==": Eq, "!=": Ne, "<": Lt, "<=": Le, ">": Gt, ">=": Ge}
+	lhs, err := p.bitOr()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := ops[p.peek()]
+	if !ok {
+		return lhs, nil
+	}
+	p.next()
+	rhs, err := p.bitOr()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ops[p.peek()]; ok {
+		return nil, fmt.Errorf("chained comparison")
+	}
+	return NewNode(Binary, &BinaryField{Operation: op, LHS: lhs, RHS: rhs}), nil
+}
+
+func (p *exprParser) bitOr() (*Node, error) {
+	return p.binary(map[string]Operation{"|": BitOr}, p.bitXor)
+}
+
+func (p *exprParser) bitXor() (*Node, error) {
+	return p.binary(map[string]Operation{"^": BitXor}, p.bitAnd)
+}
+
+func (p *exprParser) bitAnd() (*Node, error) {
+	return p.binary(map[string]Operation{"&": BitAnd}, p.shift)
+}
+
+func (p *exprParser) shift() (*Node, error) {
+	return p.binary(map[string]Operation{"<<": Shl, ">>": Shr}, p.add)
+}
+
+func (p *exprParser) add() (*Node, error) {
+	return p.binary(map[string]Operation{"+": Add, "-": Sub}, p.mul)
+}
+
+func (p *exprParser) mul() (*Node, error) {
+	return p.binary(map[string]Operation{"*": Mul, "/": Div, "//": Div, "%": Mod}, p.unary)
+}
+
+func (p *exprParser) unary() (*Node, error) {
+	ops := map[string]Operation{"-": Neg, "+": Plus, "~": BitNot}
+	op, ok := ops[p.peek()]
+	if !ok {
+		return p.primary()
+	}
+	p.next()
+	value, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return NewNode(Unary, &UnaryField{Operation: op, Value: value}), nil
+}
+
+func (p *exprParser) primary() (*Node, error) {
+	tok := p.next()
+	switch {
+	case tok == "(":
+		node, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return node, nil
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end")
+	case '0' <= tok[0] && tok[0] <= '9':
+		i, err := strconv.Atoi(tok)
+		if err != nil {
+			return nil, err
+		}
+		return NewNode(Literal, &LiteralField{TType: Integer, I: i}), nil
+	case p.peek() == "(":
+		// 除算と剰余の補助関数は二項演算に戻す
+		ops := map[string]Operation{"_cdiv": Div, "_cmod": Mod}
+		op, ok := ops[tok]
+		if !ok {
+			return nil, fmt.Errorf("unknown function: %s", tok)
+		}
+		p.next()
+		lhs, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != "," {
+			return nil, fmt.Errorf("missing ,")
+		}
+		rhs, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return NewNode(Binary, &BinaryField{Operation: op, LHS: lhs, RHS: rhs}), nil
+	default:
+		return NewNode(Ident, &IdentField{S: tok}), nil
+	}
+}
+
+func TestGenDivision(t *testing.T) {
+	div := func(op interlang.Operation, lhs, rhs *interlang.Node) *interlang.Node {
+		return build.Call("f", build.Bin(op, lhs, rhs))
+	}
+	p := &interlang.Program{
+		Imports: []*interlang.Import{{Name: "stdlib.h"}},
+		Funcs: []*interlang.Node{
+			build.Func("f", build.Int, build.Params(build.Param("x", build.Int)), build.Block(build.Return(build.Id("x")))),
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("a", build.Int, build.IntLit(-7)),
+				div(build.Div, build.IntLit(7), build.IntLit(2)),
+				div(build.Mod, build.Bin(build.Add, build.IntLit(7), build.IntLit(1)), build.IntLit(2)),
+				div(build.Div, build.Id("a"), build.IntLit(2)),
+				div(build.Mod, build.Id("a"), build.IntLit(2)),
+				div(build.Div, build.FloatLit(7), build.FloatLit(2)),
+				// 型は被演算子で決まる
+				build.Define("g", build.Float, build.Bin(build.Div, build.Var("a", build.Int), build.FloatLit(2))),
+				build.Define("h", build.Float, interlang.NewNode(interlang.Binary, &interlang.BinaryField{
+					TType: interlang.Float, Operation: interlang.Div, LHS: build.Id("a"), RHS: build.IntLit(2),
+				})),
+				build.Return(build.Un(build.Neg, build.Bin(build.Div, build.Id("a"), build.IntLit(2)))),
+			)),
+		},
+	}
+	nodes, err := ConvertProgramFromInterLang(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Gen(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "import sys\n" +
+		"def _cdiv(a, b):\n" +
+		"    q = abs(a) // abs(b)\n" +
+		"    return q if (a < 0) == (b < 0) else -q\n" +
+		"def _cmod(a, b):\n" +
+		"    r = abs(a) % abs(b)\n" +
+		"    return r if a >= 0 else -r\n" +
+		"def f(x):\n" +
+		"    return x\n" +
+		"def main():\n" +
+		"    a = -7\n" +
+		"    f(7 // 2)\n" +
+		"    f((7 + 1) % 2)\n" +
+		"    f(_cdiv(a, 2))\n" +
+		"    f(_cmod(a, 2))\n" +
+		"    f(7.0 / 2.0)\n" +
+		"    g = a / 2.0\n" +
+		"    h = _cdiv(a, 2)\n" +
+		"    return -_cdiv(a, 2)\n" +
+		"if __name__ == \"__main__\":\n    main()"
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
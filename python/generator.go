@@ -0,0 +1,153 @@
+package python
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Options 生成するコードの体裁と対象
+type Options struct {
+	// Indent 一段分の字下げ。空なら空白4つ
+	Indent string
+	// LineEnding 行末。空なら"\n"
+	LineEnding string
+	// Version 対象のpythonのバージョン。使えない構文が必要になればエラーにする
+	Version Version
+	// TypeHints 仮引数、戻り値、型付きの代入にPEP 484の型ヒントを付ける
+	TypeHints bool
+	// Header 先頭に置くコメント。改行ごとに一行のコメントにする
+	Header string
+}
+
+// Version pythonのバージョン
+// ゼロ値はバージョンによる制限を設けない
+type Version struct {
+	Major, Minor int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// before vがmajor.minorより前のバージョンか
+func (v Version) before(major, minor int) bool {
+	if v == (Version{}) {
+		return false
+	}
+	return v.Major < major || v.Major == major && v.Minor < minor
+}
+
+// Generator pythonのノードからコードを生成する
+// 生成中の状態は呼び出しごとに作るので、複数のgoroutineから同時に使ってよい
+type Generator struct {
+	opts Options
+}
+
+// NewGenerator 省略した体裁を既定値で補ったGeneratorを作る
+func NewGenerator(opts Options) *Generator {
+	if opts.Indent == "" {
+		opts.Indent = "    "
+	}
+	if opts.LineEnding == "" {
+		opts.LineEnding = "\n"
+	}
+	return &Generator{opts}
+}
+
+// generator 一回の生成の状態
+type generator struct {
+	opts Options
+	nest int
+	// loops 生成中の文を囲むループ(末尾が最も内側)
+	loops []*loop
+	// usedHelpers 生成中のコードで使った補助関数の名前
+	usedHelpers map[string]bool
+	// usedModules 生成中のコードで使ったモジュールの名前
+	usedModules map[string]bool
+	// functions 生成中のモジュールで定義した関数の名前
+	functions map[string]bool
+}
+
+// Generate モジュール全体のコードをwに書き出す
+// 生成に失敗した場合は何も書き出さない
+func (gen *Generator) Generate(w io.Writer, nodes []*Node) error {
+	if v := gen.opts.Version; v != (Version{}) && v.Major != 3 {
+		return fmt.Errorf("unsupported python version: %v", v)
+	}
+	g := &generator{
+		opts:        gen.opts,
+		usedHelpers: map[string]bool{},
+		usedModules: map[string]bool{},
+		functions:   map[string]bool{},
+	}
+	for _, node := range nodes {
+		if node.GetKind() == FunctionDefine {
+			g.functions[node.GetField().(*FunctionDefineField).Ident.GetField().(*IdentField).S] = true
+		}
+	}
+	lines, err := g.genProgram(nodes)
+	if err != nil {
+		return err
+	}
+	// 追加のimportと補助関数はimportの直後に置く
+	imports := 0
+	for imports < len(nodes) && nodes[imports].GetKind() == Import {
+		delete(g.usedModules, nodes[imports].GetField().(*ImportField).Module)
+		imports++
+	}
+	lines = append(lines[:imports], append(append(g.genModules(), g.genHelpers()...), lines[imports:]...)...)
+
+	var head []*line
+	if g.opts.Header != "" {
+		for _, s := range strings.Split(g.opts.Header, "\n") {
+			head = append(head, newLine(strings.TrimRight("# "+s, " "), 0))
+		}
+	}
+	if g.opts.TypeHints && g.opts.Version.before(3, 9) {
+		// list[int]のような型ヒントを評価させない
+		if g.opts.Version.before(3, 7) {
+			return fmt.Errorf("type hints require python 3.7 or later")
+		}
+		head = append(head, newLine("from __future__ import annotations", 0))
+	}
+	lines = append(head, lines...)
+
+	bw := bufio.NewWriter(w)
+	for _, l := range lines {
+		for i := 0; i < l.n; i++ {
+			bw.WriteString(g.opts.Indent)
+		}
+		bw.WriteString(l.s)
+		bw.WriteString(g.opts.LineEnding)
+	}
+	bw.WriteString("if __name__ == \"__main__\":" + g.opts.LineEnding + g.opts.Indent + "main()")
+	return bw.Flush()
+}
+
+// require 対象のバージョンでfeatureが使えるか確かめる
+func (g *generator) require(node *Node, major, minor int, feature string) error {
+	if g.opts.Version.before(major, minor) {
+		return fmt.Errorf("%v: %s requires python %d.%d or later", node.GetSpan(), feature, major, minor)
+	}
+	return nil
+}
+
+// Gen 既定の体裁でコードを生成する
+func Gen(nodes []*Node) (string, error) {
+	return genString(Options{}, nodes)
+}
+
+// GenWithTypeHints 仮引数、戻り値、型付きの代入にPEP 484の型ヒントを付けて生成する
+func GenWithTypeHints(nodes []*Node) (string, error) {
+	return genString(Options{TypeHints: true}, nodes)
+}
+
+func genString(opts Options, nodes []*Node) (string, error) {
+	var b strings.Builder
+	if err := NewGenerator(opts).Generate(&b, nodes); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
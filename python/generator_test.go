@@ -0,0 +1,194 @@
+package python
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"errors"
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGenerator(t *testing.T) {
+	p := &interlang.Program{
+		Globals: []*interlang.Node{build.Define("n", build.Int, build.IntLit(3))},
+		Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(
+			build.If(build.Bin(build.Gt, build.Id("n"), build.IntLit(0)), build.Block(
+				build.Return(build.IntLit(1)),
+			)),
+			build.Return(build.IntLit(0)),
+		))},
+	}
+	nodes, err := ConvertProgramFromInterLang(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		opts   Options
+		expect string
+	}{
+		{
+			"default",
+			Options{},
+			"n = 3\n" +
+				"def main():\n" +
+				"    if n > 0:\n" +
+				"        return 1\n" +
+				"    return 0\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"indent and line ending",
+			Options{Indent: "\t", LineEnding: "\r\n"},
+			"n = 3\r\n" +
+				"def main():\r\n" +
+				"\tif n > 0:\r\n" +
+				"\t\treturn 1\r\n" +
+				"\treturn 0\r\n" +
+				"if __name__ == \"__main__\":\r\n\tmain()",
+		},
+		{
+			"header",
+			Options{Header: "generated by cape\n\ndo not edit"},
+			"# generated by cape\n" +
+				"#\n" +
+				"# do not edit\n" +
+				"n = 3\n" +
+				"def main():\n" +
+				"    if n > 0:\n" +
+				"        return 1\n" +
+				"    return 0\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"type hints for old python",
+			Options{TypeHints: true, Version: Version{3, 8}},
+			"from __future__ import annotations\n" +
+				"n: int = 3\n" +
+				"def main() -> int:\n" +
+				"    if n > 0:\n" +
+				"        return 1\n" +
+				"    return 0\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := NewGenerator(tt.opts).Generate(&b, nodes); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expect, b.String()); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestGeneratorVersion(t *testing.T) {
+	walrus := build.While(build.Bin(build.Ne, build.Assign(build.Id("c"), build.Call("getchar")), build.Id("EOF")), build.Block())
+	tests := []struct {
+		name    string
+		opts    Options
+		stmt    *interlang.Node
+		wantErr bool
+	}{
+		{"assignment expression", Options{Version: Version{3, 8}}, walrus, false},
+		{"assignment expression before 3.8", Options{Version: Version{3, 7}}, walrus, true},
+		{"scanf count before 3.8", Options{Version: Version{3, 7}}, build.Return(build.Call("scanf", build.Str("%d"), build.Un(build.Addr, build.Id("a")))), true},
+		{"type hints before 3.7", Options{Version: Version{3, 6}, TypeHints: true}, build.Return(build.IntLit(0)), true},
+		{"python 2", Options{Version: Version{2, 7}}, build.Return(build.IntLit(0)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &interlang.Program{
+				Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(tt.stmt))},
+			}
+			nodes, err := ConvertProgramFromInterLang(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			err = NewGenerator(tt.opts).Generate(&b, nodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil && b.Len() != 0 {
+				t.Fatalf("wrote output despite the error: %q", b.String())
+			}
+		})
+	}
+}
+
+// TestGeneratorConcurrent 同じGeneratorを複数のgoroutineから使っても結果が混ざらないことを確かめる
+func TestGeneratorConcurrent(t *testing.T) {
+	var programs [][]*Node
+	var expects []string
+	for i := 0; i < 8; i++ {
+		// 補助関数を使うものと使わないものを混ぜる
+		var stmt *interlang.Node
+		if i%2 == 0 {
+			stmt = build.Return(build.Bin(build.Div, build.Id("a"), build.IntLit(i+1)))
+		} else {
+			stmt = build.Return(build.IntLit(i))
+		}
+		p := &interlang.Program{
+			Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(build.Param("a", build.Int)), build.Block(stmt))},
+		}
+		nodes, err := ConvertProgramFromInterLang(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, err := Gen(nodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		programs = append(programs, nodes)
+		expects = append(expects, expect)
+	}
+
+	gen := NewGenerator(Options{})
+	var wg sync.WaitGroup
+	errs := make([]error, len(programs)*10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var b strings.Builder
+			if err := gen.Generate(&b, programs[i%len(programs)]); err != nil {
+				errs[i] = err
+				return
+			}
+			if got := b.String(); got != expects[i%len(programs)] {
+				errs[i] = errors.New(cmp.Diff(expects[i%len(programs)], got))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestGeneratorWriteError(t *testing.T) {
+	nodes, err := ConvertProgramFromInterLang(&interlang.Program{
+		Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(build.Return(build.IntLit(0))))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewGenerator(Options{}).Generate(failingWriter{}, nodes); err == nil || err.Error() != "disk full" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package python
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// helper 生成したコードから呼ぶ補助関数
+// Cと意味が異なる演算を置き換えるために使い、使われたものだけをモジュールの先頭に置く
+type helper struct {
+	name   string
+	params []string
+	// 型ヒントを付ける場合の仮引数と戻り値の型
+	// 空文字列の仮引数には型を付けない
+	hints   []string
+	returns string
+	// 行頭のタブの数だけ一段ずつ深くする
+	body []string
+	// valueがあれば関数ではなく、この値で初期化する変数を定義する
+	// 型ヒントにはreturnsを使う
+	value string
+	// 本体から使う補助関数とモジュール
+	deps    []string
+	modules []string
+}
+
+// helpers 定義する順に並べた補助関数
+var helpers = []*helper{
+	{
+		// Cの整数の除算は0に向かって切り捨てる
+		name:    "_cdiv",
+		params:  []string{"a", "b"},
+		hints:   []string{"int", "int"},
+		returns: "int",
+		body: []string{
+			"q = abs(a) // abs(b)",
+			"return q if (a < 0) == (b < 0) else -q",
+		},
+	},
+	{
+		// Cの剰余の符号は被除数と同じになる
+		name:    "_cmod",
+		params:  []string{"a", "b"},
+		hints:   []string{"int", "int"},
+		returns: "int",
+		body: []string{
+			"r = abs(a) % abs(b)",
+			"return r if a >= 0 else -r",
+		},
+	},
+	{
+		// 標準入力から読みすぎた文字を戻しておく
+		name:    "_unread",
+		returns: "list[str]",
+		value:   "[]",
+	},
+	{
+		// 標準入力から一文字読む
+		// 終わりに達していれば空文字列を返す
+		name:    "_getc",
+		returns: "str",
+		body: []string{
+			"if _unread:",
+			"\treturn _unread.pop()",
+			"return sys.stdin.read(1)",
+		},
+		deps:    []string{"_unread"},
+		modules: []string{"sys"},
+	},
+	{
+		name:    "_getchar",
+		returns: "int",
+		body: []string{
+			"c = _getc()",
+			"return ord(c) if c else -1",
+		},
+		deps: []string{"_getc"},
+	},
+	{
+		// 改行までの一行をn - 1文字まで読み、読めた行と格納先の値を返す
+		// 何も読めなければCのNULLの代わりにNoneを返し、格納先にはbufをそのまま返す
+		name:    "_fgets",
+		params:  []string{"n", "buf"},
+		hints:   []string{"int", ""},
+		returns: "tuple",
+		body: []string{
+			`s = ""`,
+			"while len(s) < n - 1:",
+			"\tc = _getc()",
+			"\tif not c:",
+			"\t\tbreak",
+			"\ts += c",
+			"\t" + `if c == "\n":`,
+			"\t\tbreak",
+			"return (s, s) if s else (None, buf)",
+		},
+		deps: []string{"_getc"},
+	},
+	{
+		// cが変換指定verbの字句の続きになれるか
+		name:    "_scan_accepts",
+		params:  []string{"verb", "tok", "c"},
+		hints:   []string{"str", "str", "str"},
+		returns: "bool",
+		body: []string{
+			`if verb == "c":`,
+			"\treturn True",
+			`if verb == "s":`,
+			"\treturn not c.isspace()",
+			`if c in "+-":`,
+			"\t" + `return not tok or verb in "feEgG" and tok[-1] in "eE"`,
+			`if verb in "xX":`,
+			"\t" + `return c in "0123456789abcdefABCDEF"`,
+			`if verb == "o":`,
+			"\t" + `return c in "01234567"`,
+			`if verb in "feEgG":`,
+			"\t" + `return c.isdigit() or c in ".eE"`,
+			"return c.isdigit()",
+		},
+	},
+	{
+		// 変換指定一つ分の字句を読む
+		// 字句の前で入力が終わればNoneを返す
+		name:    "_scan_token",
+		params:  []string{"verb", "width"},
+		hints:   []string{"str", "int"},
+		returns: `"str | None"`,
+		body: []string{
+			"c = _getc()",
+			`if verb != "c":`,
+			"\twhile c.isspace():",
+			"\t\tc = _getc()",
+			"if not c:",
+			"\treturn None",
+			`tok = ""`,
+			"while c and (not width or len(tok) < width) and _scan_accepts(verb, tok, c):",
+			"\ttok += c",
+			"\tc = _getc()",
+			"if c:",
+			"\t_unread.append(c)",
+			"return tok",
+		},
+		deps: []string{"_getc", "_unread", "_scan_accepts"},
+	},
+	{
+		// 字句を変換指定の型の値にする
+		// 数として読めなければValueErrorになる
+		name:    "_scan_value",
+		params:  []string{"verb", "tok"},
+		hints:   []string{"str", "str"},
+		returns: `"int | float | str"`,
+		body: []string{
+			`if verb in "diu":`,
+			"\treturn int(tok)",
+			`if verb in "xX":`,
+			"\treturn int(tok, 16)",
+			`if verb == "o":`,
+			"\treturn int(tok, 8)",
+			`if verb in "feEgG":`,
+			"\treturn float(tok)",
+			`if verb == "c":`,
+			"\treturn ord(tok) if len(tok) == 1 else tok",
+			"return tok",
+		},
+	},
+	{
+		// Cのscanfと同じく書式に従って読み、読めた数と格納先の値を返す
+		// 読めなかった格納先にはvaluesの値をそのまま返す
+		// 一つも読まないうちに入力が終われば-1(EOF)を返す
+		name:    "_scanf",
+		params:  []string{"fmt", "*values"},
+		hints:   []string{"str", ""},
+		returns: "tuple",
+		body: []string{
+			"result = list(values)",
+			"count = 0",
+			"i = 0",
+			"while i < len(fmt):",
+			"\tf = fmt[i]",
+			"\ti += 1",
+			"\tif f.isspace():",
+			"\t\tc = _getc()",
+			"\t\twhile c.isspace():",
+			"\t\t\tc = _getc()",
+			"\t\tif c:",
+			"\t\t\t_unread.append(c)",
+			"\t\tcontinue",
+			"\t" + `if f == "%" and fmt[i] != "%":`,
+			"\t\t" + `skip = fmt[i] == "*"`,
+			"\t\ti += skip",
+			"\t\twidth = 0",
+			"\t\twhile fmt[i].isdigit():",
+			"\t\t\twidth = width * 10 + int(fmt[i])",
+			"\t\t\ti += 1",
+			"\t\t" + `while fmt[i] in "hlLqjzt":`,
+			"\t\t\ti += 1",
+			"\t\tverb = fmt[i]",
+			"\t\ti += 1",
+			"\t\t" + `tok = _scan_token(verb, width or (verb == "c"))`,
+			"\t\tif tok is None:",
+			"\t\t\treturn (count or -1, *result)",
+			"\t\ttry:",
+			"\t\t\tvalue = _scan_value(verb, tok)",
+			"\t\texcept ValueError:",
+			"\t\t\tbreak",
+			"\t\tif not skip:",
+			"\t\t\tresult[count] = value",
+			"\t\t\tcount += 1",
+			"\t\tcontinue",
+			"\t" + `i += f == "%"`,
+			"\tc = _getc()",
+			"\tif c != f:",
+			"\t\tif not c:",
+			"\t\t\treturn (count or -1, *result)",
+			"\t\t_unread.append(c)",
+			"\t\tbreak",
+			"return (count, *result)",
+		},
+		deps: []string{"_getc", "_unread", "_scan_token", "_scan_value"},
+	},
+}
+
+// useHelper 補助関数を使ったことを記録して名前を返す
+// 補助関数から使うものも合わせて記録する
+func (g *generator) useHelper(name string) string {
+	if g.usedHelpers[name] {
+		return name
+	}
+	g.usedHelpers[name] = true
+	for _, h := range helpers {
+		if h.name != name {
+			continue
+		}
+		for _, dep := range h.deps {
+			g.useHelper(dep)
+		}
+		for _, module := range h.modules {
+			g.usedModules[module] = true
+		}
+	}
+	return name
+}
+
+// genModules 使ったモジュールのimport
+func (g *generator) genModules() []*line {
+	var modules []string
+	for module := range g.usedModules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	var lines []*line
+	for _, module := range modules {
+		lines = append(lines, newLine(fmt.Sprintf("import %s", module), 0))
+	}
+	return lines
+}
+
+// genHelpers 使った補助関数の定義
+func (g *generator) genHelpers() []*line {
+	var lines []*line
+	for _, h := range helpers {
+		if !g.usedHelpers[h.name] {
+			continue
+		}
+		if h.value != "" {
+			if g.opts.TypeHints {
+				lines = append(lines, newLine(fmt.Sprintf("%s: %s = %s", h.name, h.returns, h.value), 0))
+			} else {
+				lines = append(lines, newLine(fmt.Sprintf("%s = %s", h.name, h.value), 0))
+			}
+			continue
+		}
+		params := ""
+		for i, param := range h.params {
+			if i != 0 {
+				params += ", "
+			}
+			params += param
+			if g.opts.TypeHints && h.hints[i] != "" {
+				params += ": " + h.hints[i]
+			}
+		}
+		returns := ""
+		if g.opts.TypeHints {
+			returns = " -> " + h.returns
+		}
+		lines = append(lines, newLine(fmt.Sprintf("def %s(%s)%s:", h.name, params, returns), 0))
+		for _, s := range h.body {
+			body := strings.TrimLeft(s, "\t")
+			lines = append(lines, newLine(body, 1+len(s)-len(body)))
+		}
+	}
+	return lines
+}
+
+// nonNegative 式の値が負にならないと分かるか
+func nonNegative(node *Node) bool {
+	switch field := node.GetField().(type) {
+	case *LiteralField:
+		switch field.GetTType() {
+		case Integer, Bool:
+			return field.I >= 0
+		case Float:
+			return field.F >= 0
+		}
+	case *BinaryField:
+		switch field.Operation {
+		case Eq, Ne, Lt, Le, Gt, Ge:
+			return true
+		case BitAnd:
+			return nonNegative(field.LHS) || nonNegative(field.RHS)
+		case Add, Mul, Div, Mod, BitOr, BitXor, Shl, Shr, And, Or:
+			return nonNegative(field.LHS) && nonNegative(field.RHS)
+		}
+	case *NotField:
+		return true
+	case *UnaryField:
+		return field.Operation == Plus && nonNegative(field.Value)
+	}
+	return false
+}
@@ -0,0 +1,400 @@
+package python
+
+import (
+	"fmt"
+	"strings"
+)
+
+// conversion printfの書式の変換指定 %[flags][width][.precision][length]verb
+type conversion struct {
+	flags     string
+	width     string
+	precision string
+	length    string
+	verb      byte
+}
+
+// formatPart 書式を分けたもの
+// conversionがnilなら地の文
+type formatPart struct {
+	text       string
+	conversion *conversion
+}
+
+// parseFormat Cの書式を地の文と変換指定に分ける
+func parseFormat(s string) ([]formatPart, error) {
+	var parts []formatPart
+	var text strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			text.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i < len(s) && s[i] == '%' {
+			text.WriteByte('%')
+			continue
+		}
+		c := &conversion{}
+		start := i
+		for i < len(s) && strings.IndexByte("-+ #0", s[i]) >= 0 {
+			i++
+		}
+		c.flags = s[start:i]
+		start = i
+		for i < len(s) && (s[i] == '*' || isDigit(s[i])) {
+			i++
+		}
+		c.width = s[start:i]
+		if i < len(s) && s[i] == '.' {
+			start = i
+			i++
+			for i < len(s) && (s[i] == '*' || isDigit(s[i])) {
+				i++
+			}
+			c.precision = s[start:i]
+		}
+		start = i
+		for i < len(s) && strings.IndexByte("hlLqjzt", s[i]) >= 0 {
+			i++
+		}
+		c.length = s[start:i]
+		if i >= len(s) {
+			return nil, fmt.Errorf("incomplete format: %q", s)
+		}
+		c.verb = s[i]
+		if strings.IndexByte("diuoxXcsfFeEgG", c.verb) < 0 {
+			return nil, fmt.Errorf("unsupported format verb: %%%c", c.verb)
+		}
+		if c.verb == 'o' && strings.Contains(c.flags, "#") {
+			// pythonの%#oは0o17になり、Cの017と合わない
+			return nil, fmt.Errorf("unsupported format flag: %%%so", c.flags)
+		}
+
+		if text.Len() != 0 {
+			parts = append(parts, formatPart{text: text.String()})
+			text.Reset()
+		}
+		parts = append(parts, formatPart{conversion: c})
+	}
+	if text.Len() != 0 {
+		parts = append(parts, formatPart{text: text.String()})
+	}
+	return parts, nil
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// args 変換指定が受け取る引数の数(*の幅と精度を含む)
+func (c *conversion) args() int {
+	n := 1
+	if c.width == "*" {
+		n++
+	}
+	if c.precision == ".*" {
+		n++
+	}
+	return n
+}
+
+// python pythonの%書式での表記
+// 長さ修飾子は不要なので取り除き、%iと%uは%dにする
+func (c *conversion) python() string {
+	verb := c.verb
+	switch verb {
+	case 'i', 'u':
+		verb = 'd'
+	}
+	return "%" + c.flags + c.width + c.precision + string(verb)
+}
+
+// unsignedMask 符号なしとして表示する変換で、負の数を2の補数として扱うためのマスク
+// 符号なしの変換でなければ空文字列を返す
+func (c *conversion) unsignedMask() string {
+	switch c.verb {
+	case 'u', 'o', 'x', 'X':
+	default:
+		return ""
+	}
+	switch c.length {
+	case "l", "ll", "q", "j", "z", "t":
+		return "0xffffffffffffffff"
+	case "h":
+		return "0xffff"
+	case "hh":
+		return "0xff"
+	default:
+		return "0xffffffff"
+	}
+}
+
+// stdioFunction 書式付きの入出力などの標準ライブラリの関数か
+// 同じ名前の関数が定義されていればその関数を呼ぶ
+func (g *generator) stdioFunction(name string) bool {
+	if g.functions[name] {
+		return false
+	}
+	switch name {
+	case "printf", "fprintf", "puts", "putchar", "sprintf", "snprintf", "scanf", "getchar", "fgets":
+		return true
+	default:
+		return false
+	}
+}
+
+// genStdioCall 式としての標準ライブラリの関数の呼び出し
+// printf、fprintf、puts、putcharはprintにする
+func (g *generator) genStdioCall(node *Node, name string, args []*Node) (string, error) {
+	switch name {
+	case "printf":
+		return g.genPrint(node, args, "")
+	case "fprintf":
+		if len(args) == 0 {
+			return "", fmt.Errorf("%v: fprintf requires a stream", node.GetSpan())
+		}
+		file, err := g.genStream(args[0])
+		if err != nil {
+			return "", err
+		}
+		return g.genPrint(node, args[1:], file)
+	case "puts":
+		if len(args) != 1 {
+			return "", fmt.Errorf("%v: puts requires one argument", node.GetSpan())
+		}
+		s, err := g.genExpr(args[0])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("print(%s)", s), nil
+	case "putchar":
+		if len(args) != 1 {
+			return "", fmt.Errorf("%v: putchar requires one argument", node.GetSpan())
+		}
+		c, err := g.genExpr(args[0])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("print(chr(%s), end=\"\")", c), nil
+	case "getchar":
+		if len(args) != 0 {
+			return "", fmt.Errorf("%v: getchar takes no arguments", node.GetSpan())
+		}
+		return g.useHelper("_getchar") + "()", nil
+	case "scanf":
+		return g.genScanfExpr(node, args)
+	case "fgets":
+		return g.genFgets(node, args, true)
+	default:
+		// sprintfは書き込み先への代入になるので文としてだけ書ける
+		return "", fmt.Errorf("%v: the result of %s is not supported", node.GetSpan(), name)
+	}
+}
+
+// genStream fprintfの出力先
+// stdoutなら空文字列にする
+func (g *generator) genStream(node *Node) (string, error) {
+	if node.GetKind() == Ident {
+		switch node.GetField().(*IdentField).S {
+		case "stdout":
+			return "", nil
+		case "stderr":
+			g.usedModules["sys"] = true
+			return "sys.stderr", nil
+		}
+	}
+	return g.genExpr(node)
+}
+
+// genPrint 書式と引数をprintにする
+// 書式が改行で終わっていればprintの改行に任せ、そうでなければend=""を渡す
+func (g *generator) genPrint(node *Node, args []*Node, file string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("%v: missing format", node.GetSpan())
+	}
+	var options string
+	s, newline, err := g.genFormat(node, args[0], args[1:], true)
+	if err != nil {
+		return "", err
+	}
+	if !newline {
+		options += ", end=\"\""
+	}
+	if file != "" {
+		options += ", file=" + file
+	}
+	if s == `""` && newline {
+		s = ""
+		options = strings.TrimPrefix(options, ", ")
+	}
+	return fmt.Sprintf("print(%s%s)", s, options), nil
+}
+
+// genFormat 書式で整形した文字列を作る式
+// trimが真なら書式の末尾の改行を取り除き、取り除いたかをtrimNewlineで返す
+func (g *generator) genFormat(node *Node, format *Node, args []*Node, trim bool) (s string, trimNewline bool, err error) {
+	literalField, ok := format.GetField().(*LiteralField)
+	if !ok || literalField.GetTType() != String {
+		// 書式が変数なら、実行時にpythonの%書式として解釈させる
+		f, err := g.genMul(format)
+		if err != nil {
+			return "", false, err
+		}
+		values, err := g.genValues(args)
+		if err != nil {
+			return "", false, err
+		}
+		return fmt.Sprintf("%s %% %s", f, values), false, nil
+	}
+
+	parts, err := parseFormat(literalField.S)
+	if err != nil {
+		return "", false, fmt.Errorf("%v: %v", node.GetSpan(), err)
+	}
+	if trim && len(parts) != 0 && parts[len(parts)-1].conversion == nil && strings.HasSuffix(parts[len(parts)-1].text, "\n") {
+		last := &parts[len(parts)-1]
+		last.text = strings.TrimSuffix(last.text, "\n")
+		trimNewline = true
+	}
+
+	var b strings.Builder
+	var values []*Node
+	for _, part := range parts {
+		if part.conversion == nil {
+			b.WriteString(part.text)
+			continue
+		}
+		c := part.conversion
+		b.WriteString(c.python())
+		n := c.args()
+		if len(args) < n {
+			return "", false, fmt.Errorf("%v: too few arguments for format %q", node.GetSpan(), literalField.S)
+		}
+		for i := 0; i < n-1; i++ {
+			values = append(values, args[i])
+		}
+		value := args[n-1]
+		if mask := c.unsignedMask(); mask != "" && !nonNegative(value) {
+			// 64ビットのマスクはintに収まらないので、16進数の表記をそのまま置く
+			value = NewNode(Binary, &BinaryField{Integer, BitAnd, value, NewNode(Ident, &IdentField{S: mask})})
+		}
+		values = append(values, value)
+		args = args[n:]
+	}
+	if len(args) != 0 {
+		return "", false, fmt.Errorf("%v: too many arguments for format %q", node.GetSpan(), literalField.S)
+	}
+
+	// 引数がなければ%演算子を使わないので、%%をエスケープしない
+	if len(values) == 0 {
+		return Quote(b.String()), trimNewline, nil
+	}
+	var python strings.Builder
+	for _, part := range parts {
+		if part.conversion == nil {
+			python.WriteString(strings.ReplaceAll(part.text, "%", "%%"))
+		} else {
+			python.WriteString(part.conversion.python())
+		}
+	}
+	v, err := g.genValues(values)
+	if err != nil {
+		return "", false, err
+	}
+	return fmt.Sprintf("%s %% %s", Quote(python.String()), v), trimNewline, nil
+}
+
+// genValues %演算子の右辺に置くタプル
+func (g *generator) genValues(values []*Node) (string, error) {
+	var vs []string
+	for _, value := range values {
+		v, err := g.genExpr(value)
+		if err != nil {
+			return "", err
+		}
+		vs = append(vs, v)
+	}
+	if len(vs) == 1 {
+		return "(" + vs[0] + ",)", nil
+	}
+	return "(" + strings.Join(vs, ", ") + ")", nil
+}
+
+// genSprintf sprintf(buf, format, ...)とsnprintf(buf, n, format, ...)を書き込み先への代入にする
+// snprintfのnが0以下なら何も書き込まない
+func (g *generator) genSprintf(node *Node, name string, args []*Node) ([]*line, error) {
+	n := 1
+	if name == "snprintf" {
+		n = 2
+	}
+	if len(args) < n+1 {
+		return nil, fmt.Errorf("%v: too few arguments to %s", node.GetSpan(), name)
+	}
+	buf, err := g.genExpr(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s, _, err := g.genFormat(node, args[n], args[n+1:], false)
+	if err != nil {
+		return nil, err
+	}
+	if name != "snprintf" {
+		return []*line{newLine(fmt.Sprintf("%s = %s", buf, s), g.nest)}, nil
+	}
+
+	// 終端のNULの分を除いた長さまでに切り詰める
+	if args[1].GetKind() == Literal && args[1].GetField().(*LiteralField).GetTType() == Integer {
+		size := args[1].GetField().(*LiteralField).I
+		if size <= 0 {
+			return nil, nil
+		}
+		return []*line{newLine(fmt.Sprintf("%s = (%s)[:%d]", buf, s, size-1), g.nest)}, nil
+	}
+	size, err := g.genExpr(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end, err := g.genExpr(NewNode(Binary, &BinaryField{Integer, Sub, args[1], NewNode(Literal, &LiteralField{TType: Integer, I: 1})}))
+	if err != nil {
+		return nil, err
+	}
+	return []*line{
+		newLine(fmt.Sprintf("if %s > 0:", size), g.nest),
+		newLine(fmt.Sprintf("%s = (%s)[:%s]", buf, s, end), g.nest+1),
+	}, nil
+}
+
+// stdioCall 標準ライブラリの関数の呼び出しか
+func (g *generator) stdioCall(node *Node) (string, []*Node, bool) {
+	if node.GetKind() != Call {
+		return "", nil, false
+	}
+	callField := node.GetField().(*CallField)
+	name := callField.Ident.GetField().(*IdentField).S
+	if !g.stdioFunction(name) {
+		return "", nil, false
+	}
+	return name, callField.Args.GetField().(*MultipleField).Values, true
+}
+
+// genStdioStmt 文としての標準ライブラリの関数の呼び出し
+// 引数の指す先へ書き込む関数は代入文にする
+func (g *generator) genStdioStmt(node *Node, name string, args []*Node) ([]*line, error) {
+	var s string
+	var err error
+	switch name {
+	case "sprintf", "snprintf":
+		return g.genSprintf(node, name, args)
+	case "scanf":
+		s, err = g.genScanfStmt(node, args)
+	case "fgets":
+		s, err = g.genFgets(node, args, false)
+	default:
+		s, err = g.genStdioCall(node, name, args)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*line{newLine(s, g.nest)}, nil
+}
@@ -0,0 +1,169 @@
+package python
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestGenPrintf(t *testing.T) {
+	tests := []struct {
+		name    string
+		imports string
+		stmts   []*interlang.Node
+		expect  string
+	}{
+		{
+			"trailing newline",
+			"",
+			[]*interlang.Node{
+				build.Call("printf", build.Str("%d %s\n"), build.Id("n"), build.Str("a")),
+				build.Call("printf", build.Str("\n")),
+			},
+			"    print(\"%d %s\" % (n, \"a\"))\n" +
+				"    print()\n",
+		},
+		{
+			"no trailing newline",
+			"",
+			[]*interlang.Node{
+				build.Call("printf", build.Str("%d,"), build.Id("n")),
+				build.Call("printf", build.Str("100%%")),
+			},
+			"    print(\"%d,\" % (n,), end=\"\")\n" +
+				"    print(\"100%\", end=\"\")\n",
+		},
+		{
+			"flags width precision",
+			"",
+			[]*interlang.Node{
+				build.Call("printf", build.Str("|%-5d|%+05.2f|%*d|%.*s|%5.1e %%\n"),
+					build.Id("n"), build.FloatLit(1.5), build.IntLit(3), build.Id("n"), build.IntLit(2), build.Str("abc"), build.FloatLit(0.25)),
+			},
+			"    print(\"|%-5d|%+05.2f|%*d|%.*s|%5.1e %%\" % (n, 1.5, 3, n, 2, \"abc\", 0.25))\n",
+		},
+		{
+			"length modifiers",
+			"",
+			[]*interlang.Node{
+				build.Call("printf", build.Str("%ld %lld %hhi %zu\n"), build.Id("n"), build.Id("n"), build.Id("n"), build.IntLit(1)),
+			},
+			"    print(\"%d %d %d %d\" % (n, n, n, 1))\n",
+		},
+		{
+			"unsigned",
+			"",
+			[]*interlang.Node{
+				build.Call("printf", build.Str("%u %x %08lX %o %hx\n"), build.Id("n"), build.Bin(build.Sub, build.Id("n"), build.IntLit(1)), build.Id("n"), build.IntLit(8), build.Id("n")),
+			},
+			"    print(\"%d %x %08X %o %x\" % (n & 0xffffffff, n - 1 & 0xffffffff, n & 0xffffffffffffffff, 8, n & 0xffff))\n",
+		},
+		{
+			"variable format",
+			"",
+			[]*interlang.Node{
+				build.Define("f", build.String, build.Str("%d\n")),
+				build.Call("printf", build.Id("f"), build.Id("n")),
+			},
+			"    f = \"%d\\n\"\n" +
+				"    print(f % (n,), end=\"\")\n",
+		},
+		{
+			"puts putchar",
+			"",
+			[]*interlang.Node{
+				build.Call("puts", build.Str("hello")),
+				build.Call("putchar", build.IntLit(65)),
+			},
+			"    print(\"hello\")\n" +
+				"    print(chr(65), end=\"\")\n",
+		},
+		{
+			"fprintf",
+			"import sys\n",
+			[]*interlang.Node{
+				build.Call("fprintf", build.Id("stderr"), build.Str("error: %d\n"), build.Id("n")),
+				build.Call("fprintf", build.Id("stdout"), build.Str("ok")),
+			},
+			"    print(\"error: %d\" % (n,), file=sys.stderr)\n" +
+				"    print(\"ok\", end=\"\")\n",
+		},
+		{
+			"sprintf",
+			"",
+			[]*interlang.Node{
+				build.Declare("buf", build.String),
+				build.Call("sprintf", build.Id("buf"), build.Str("%03d"), build.Id("n")),
+				build.Call("snprintf", build.Id("buf"), build.IntLit(4), build.Str("%d\n"), build.Id("n")),
+			},
+			"    buf = None\n" +
+				"    buf = \"%03d\" % (n,)\n" +
+				"    buf = (\"%d\\n\" % (n,))[:3]\n",
+		},
+		{
+			"snprintf size",
+			"",
+			[]*interlang.Node{
+				build.Declare("buf", build.String),
+				build.Call("snprintf", build.Id("buf"), build.IntLit(0), build.Str("%d"), build.Id("n")),
+				build.Call("snprintf", build.Id("buf"), build.Id("n"), build.Str("%d"), build.Id("n")),
+			},
+			"    buf = None\n" +
+				"    if n > 0:\n" +
+				"        buf = (\"%d\" % (n,))[:n - 1]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts := append([]*interlang.Node{build.Define("n", build.Int, build.IntLit(5))}, tt.stmts...)
+			p := &interlang.Program{
+				Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(stmts...))},
+			}
+			nodes, err := ConvertProgramFromInterLang(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Gen(nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expect := tt.imports +
+				"def main():\n" +
+				"    n = 5\n" +
+				tt.expect +
+				"if __name__ == \"__main__\":\n    main()"
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestGenPrintfError(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt *interlang.Node
+	}{
+		{"too few arguments", build.Call("printf", build.Str("%d %d\n"), build.IntLit(1))},
+		{"too many arguments", build.Call("printf", build.Str("%d\n"), build.IntLit(1), build.IntLit(2))},
+		{"unsupported verb", build.Call("printf", build.Str("%p\n"), build.IntLit(1))},
+		{"alternate octal", build.Call("printf", build.Str("%#o\n"), build.IntLit(15))},
+		{"incomplete", build.Call("printf", build.Str("100%"))},
+		{"sprintf in expression", build.Return(build.Call("sprintf", build.Id("buf"), build.Str("x")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &interlang.Program{
+				Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(tt.stmt))},
+			}
+			nodes, err := ConvertProgramFromInterLang(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Gen(nodes); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package python
+
+import (
+	"cape/interlang"
+	"fmt"
+	"sort"
+)
+
+// headerModules Cのヘッダに対応するpythonのモジュール
+// 組み込み関数だけで足りるヘッダは空文字列にする
+var headerModules = map[string]string{
+	"stdio.h":   "",
+	"stdlib.h":  "sys",
+	"string.h":  "",
+	"stdbool.h": "",
+	"ctype.h":   "",
+	"limits.h":  "",
+	"assert.h":  "",
+	"math.h":    "math",
+}
+
+// ConvertProgramFromInterLang 翻訳単位全体をpythonのモジュールに変換する
+// import、型の別名、大域変数、関数の順に並べる
+func ConvertProgramFromInterLang(p *interlang.Program) ([]*Node, error) {
+	c := &converter{renames: map[string]string{}, globals: map[string]interlang.TType{}}
+	for _, r := range Renames(p) {
+		c.renames[r.From] = r.To
+	}
+
+	var nodes []*Node
+
+	imports, err := programImports(p.Imports)
+	if err != nil {
+		return nil, err
+	}
+	nodes = append(nodes, imports...)
+
+	for _, iNode := range p.Types {
+		node, err := c.typeDefine(iNode)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	for _, iNode := range p.Globals {
+		switch iField := iNode.GetField().(type) {
+		case *interlang.VariableDeclareField:
+			c.globals[identName(iField.Ident)] = iField.GetTType()
+		case *interlang.VariableDefineField:
+			c.globals[identName(iField.Ident)] = iField.GetTType()
+		}
+	}
+
+	globals := map[string]bool{}
+	for _, iNode := range p.Globals {
+		node, err := c.globalVariable(iNode)
+		if err != nil {
+			return nil, err
+		}
+		globals[node.GetField().(*AssignField).To.GetField().(*IdentField).S] = true
+		nodes = append(nodes, node)
+	}
+
+	// pythonにプロトタイプはないので、定義があることだけを確かめる
+	defined := map[string]bool{}
+	for _, iNode := range p.Funcs {
+		defined[identName(iNode.GetField().(*interlang.FunctionDefineField).Ident)] = true
+	}
+	for _, iNode := range p.Decls {
+		iField := iNode.GetField().(*interlang.FunctionDeclareField)
+		if name := identName(iField.Ident); !defined[name] {
+			return nil, fmt.Errorf("%v: function %s is declared but not defined", iNode.GetSpan(), name)
+		}
+	}
+
+	for _, iNode := range p.Funcs {
+		node, err := c.functionDefine(iNode)
+		if err != nil {
+			return nil, err
+		}
+		c.insertGlobal(node, iNode, globals)
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func identName(iNode *interlang.Node) string {
+	return iNode.GetField().(*interlang.IdentField).S
+}
+
+func programImports(iImports []*interlang.Import) ([]*Node, error) {
+	var nodes []*Node
+	seen := map[string]bool{}
+	for _, iImport := range iImports {
+		module, ok := headerModules[iImport.Name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported header: %s", iImport.Name)
+		}
+		if module == "" || seen[module] {
+			continue
+		}
+		seen[module] = true
+		nodes = append(nodes, NewNode(Import, &ImportField{Module: module}))
+	}
+	return nodes, nil
+}
+
+// typeDefine 型の別名は型を代入した変数にする
+func (c *converter) typeDefine(iNode *interlang.Node) (*Node, error) {
+	iField := iNode.GetField().(*interlang.TypeDefineField)
+	name, err := typeName(iField.TType)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", iNode.GetSpan(), err)
+	}
+	return newNode(iNode, Assign, &AssignField{
+		c.ident(iField.Ident),
+		newNode(iNode, Ident, &IdentField{S: name}),
+		nil,
+	}), nil
+}
+
+func typeName(tt interlang.TType) (string, error) {
+	switch tt {
+	case interlang.Integer:
+		return "int", nil
+	case interlang.String:
+		return "str", nil
+	case interlang.Bool:
+		return "bool", nil
+	case interlang.Float:
+		return "float", nil
+	default:
+		return "", fmt.Errorf("unsupported type: %v", tt)
+	}
+}
+
+// globalVariable 大域変数は初期値を代入する
+// 初期値がなければCと同じく型のゼロ値で初期化する
+func (c *converter) globalVariable(iNode *interlang.Node) (*Node, error) {
+	switch iNode.GetKind() {
+	case interlang.VariableDeclare:
+		return c.zeroAssign(iNode)
+	case interlang.VariableDefine:
+		return c.assign(iNode)
+	default:
+		return nil, fmt.Errorf("%v: unexpected global: %v", iNode.GetSpan(), iNode.GetKind())
+	}
+}
+
+// zeroAssign 初期値のない宣言をゼロ値の代入にする
+// ゼロ値がNoneになる型は型ヒントと合わないので型を付けない
+func (c *converter) zeroAssign(iNode *interlang.Node) (*Node, error) {
+	iField := iNode.GetField().(*interlang.VariableDeclareField)
+	value := zeroValue(iNode, iField.TType)
+	var tt TType
+	if value.GetField().(*LiteralField).GetTType() != Null {
+		var err error
+		tt, err = c.convertType(iField.GetTType())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newNode(iNode, Assign, &AssignField{c.ident(iField.Ident), value, tt}), nil
+}
+
+func zeroValue(iNode *interlang.Node, tt interlang.TType) *Node {
+	switch tt {
+	case interlang.Integer:
+		return newNode(iNode, Literal, &LiteralField{TType: Integer, I: 0})
+	case interlang.Bool:
+		return newNode(iNode, Literal, &LiteralField{TType: Bool, I: 0})
+	case interlang.Float:
+		return newNode(iNode, Literal, &LiteralField{TType: Float, F: 0})
+	default:
+		return newNode(iNode, Literal, &LiteralField{TType: Null})
+	}
+}
+
+// insertGlobal 関数の中で代入している大域変数をglobal文で宣言する
+// 同じ名前の局所変数を宣言している場合は局所変数への代入とみなす
+func (c *converter) insertGlobal(node *Node, iNode *interlang.Node, globals map[string]bool) {
+	iField := iNode.GetField().(*interlang.FunctionDefineField)
+	locals := map[string]bool{}
+	assigned := map[string]bool{}
+	collectNames(iField.Block, locals, assigned)
+	// 仮引数も局所変数
+	if iField.Params != nil {
+		for _, iParam := range iField.Params.GetField().(*interlang.MultipleField).Values {
+			locals[identName(iParam.GetField().(*interlang.ParamField).Ident)] = true
+		}
+	}
+
+	var names []string
+	for name := range assigned {
+		if globals[c.renamed(name)] && !locals[name] {
+			names = append(names, c.renamed(name))
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	block := node.GetField().(*FunctionDefineField).Block.GetField().(*BlockField)
+	global := newNode(iField.Block, Global, &GlobalField{Names: names})
+	block.Stmts = append([]*Node{global}, block.Stmts...)
+}
+
+// collectNames 宣言した変数と代入先の変数を集める
+func collectNames(iNode *interlang.Node, locals, assigned map[string]bool) {
+	if iNode == nil {
+		return
+	}
+	switch iField := iNode.GetField().(type) {
+	case *interlang.VariableDeclareField:
+		locals[identName(iField.Ident)] = true
+	case *interlang.VariableDefineField:
+		locals[identName(iField.Ident)] = true
+		collectNames(iField.Value, locals, assigned)
+	case *interlang.AssignField:
+		if iField.To.GetKind() == interlang.Ident {
+			assigned[identName(iField.To)] = true
+		}
+		collectNames(iField.Value, locals, assigned)
+	case *interlang.BlockField:
+		for _, stmt := range iField.Stmts {
+			collectNames(stmt, locals, assigned)
+		}
+	case *interlang.IfElseField:
+		collectNames(iField.Cond, locals, assigned)
+		collectNames(iField.IfBlock, locals, assigned)
+		collectNames(iField.ElseBlock, locals, assigned)
+	case *interlang.WhileField:
+		collectNames(iField.Cond, locals, assigned)
+		collectNames(iField.Block, locals, assigned)
+	case *interlang.ForField:
+		collectNames(iField.Init, locals, assigned)
+		collectNames(iField.Cond, locals, assigned)
+		collectNames(iField.Loop, locals, assigned)
+		collectNames(iField.Block, locals, assigned)
+	case *interlang.ReturnField:
+		collectNames(iField.Value, locals, assigned)
+	case *interlang.BinaryField:
+		collectNames(iField.LHS, locals, assigned)
+		collectNames(iField.RHS, locals, assigned)
+	case *interlang.UnaryField:
+		collectNames(iField.Value, locals, assigned)
+	case *interlang.NotField:
+		collectNames(iField.Value, locals, assigned)
+	case *interlang.CallField:
+		for _, name := range inputTargets(iField) {
+			assigned[name] = true
+		}
+		collectNames(iField.Args, locals, assigned)
+	case *interlang.MultipleField:
+		for _, value := range iField.Values {
+			collectNames(value, locals, assigned)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package python
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func TestConvertProgramFromInterLang(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     *interlang.Program
+		expect string
+	}{
+		{
+			"globals",
+			&interlang.Program{
+				Imports: []*interlang.Import{{Name: "stdio.h"}, {Name: "math.h"}},
+				Types:   []*interlang.Node{build.TypeDef("score", build.Int)},
+				Globals: []*interlang.Node{
+					build.Declare("count", build.Int),
+					build.Define("limit", build.Int, build.IntLit(10)),
+				},
+				Decls: []*interlang.Node{build.FuncDecl("main", build.Int, build.Params())},
+				Funcs: []*interlang.Node{
+					build.Func("main", build.Int, build.Params(), build.Block(
+						build.Define("limit", build.Int, build.IntLit(3)),
+						build.Assign(build.Id("count"), build.Id("limit")),
+						build.Return(build.Id("count")),
+					)),
+				},
+			},
+			"import math\n" +
+				"score = int\n" +
+				"count = 0\n" +
+				"limit = 10\n" +
+				"def main():\n" +
+				"    global count\n" +
+				"    limit = 3\n" +
+				"    count = limit\n" +
+				"    return count\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"uninitialized",
+			&interlang.Program{
+				Funcs: []*interlang.Node{
+					build.Func("main", build.Int, build.Params(), build.Block(
+						build.Declare("x", build.Int),
+						build.Declare("y", build.Int),
+						build.If(build.Id("y"), build.Block(build.Assign(build.Id("x"), build.IntLit(1)))),
+						build.Return(build.Id("x")),
+					)),
+				},
+			},
+			"def main():\n" +
+				"    x = 0\n" +
+				"    y = 0\n" +
+				"    if y:\n" +
+				"        x = 1\n" +
+				"    return x\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"assigned before use",
+			&interlang.Program{
+				Funcs: []*interlang.Node{
+					build.Func("main", build.Int, build.Params(), build.Block(
+						build.Declare("x", build.Int),
+						build.Assign(build.Id("x"), build.IntLit(1)),
+						build.Return(build.Id("x")),
+					)),
+				},
+			},
+			"def main():\n" +
+				"    x = 1\n" +
+				"    return x\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"strings",
+			&interlang.Program{
+				Funcs: []*interlang.Node{
+					build.Func("main", build.Int, build.Params(), build.Block(
+						build.Define("s", build.String, build.Str("a\\b\n")),
+						build.Call("printf", build.Str("say \"%d\"\n"), build.IntLit(1)),
+						build.Return(build.IntLit(0)),
+					)),
+				},
+			},
+			"def main():\n" +
+				"    s = \"a\\\\b\\n\"\n" +
+				"    print('say \"%d\"' % (1,))\n" +
+				"    return 0\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+		{
+			"params",
+			&interlang.Program{
+				Globals: []*interlang.Node{build.Define("a", build.Int, build.IntLit(0))},
+				Funcs: []*interlang.Node{
+					build.Func("add", build.Int, build.Params(build.Param("a", build.Int), build.ParamDefault("len", build.Int, build.IntLit(1))), build.Block(
+						build.Assign(build.Id("a"), build.Bin(build.Add, build.Id("a"), build.Id("len"))),
+						build.Return(build.Id("a")),
+					)),
+					build.Func("main", build.Int, build.Params(), build.Block(
+						build.Return(build.Call("add", build.IntLit(1), build.IntLit(2))),
+					)),
+				},
+			},
+			"a = 0\n" +
+				"def add(a, len_=1):\n" +
+				"    a = a + len_\n" +
+				"    return a\n" +
+				"def main():\n" +
+				"    return add(1, 2)\n" +
+				"if __name__ == \"__main__\":\n    main()",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := ConvertProgramFromInterLang(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Gen(nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestConvertProgramFromInterLangError(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     *interlang.Program
+		expect string
+	}{
+		{
+			"undefined prototype",
+			&interlang.Program{
+				Decls: []*interlang.Node{build.FuncDecl("f", build.Int, build.Params())},
+			},
+			"function f is declared but not defined",
+		},
+		{
+			"unknown header",
+			&interlang.Program{
+				Imports: []*interlang.Import{{Name: "pthread.h"}},
+			},
+			"unsupported header: pthread.h",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConvertProgramFromInterLang(tt.in)
+			if err == nil || !strings.Contains(err.Error(), tt.expect) {
+				t.Fatalf("expected %q, got %v", tt.expect, err)
+			}
+		})
+	}
+}
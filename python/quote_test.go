@@ -0,0 +1,36 @@
+package python
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		expect string
+	}{
+		{"plain", "hi", `"hi"`},
+		{"empty", "", `""`},
+		{"double quote", `say "hi"`, `'say "hi"'`},
+		{"both quotes", `"it's"`, `"\"it's\""`},
+		{"single quote", "it's", `"it's"`},
+		{"backslash", `a\b`, `"a\\b"`},
+		{"control", "a\nb\tc\rd\x1b", `"a\nb\tc\rd\x1b"`},
+		{"nul before digit", "\x001", `"\x001"`},
+		{"delete", "\x7f", `"\x7f"`},
+		{"latin1", "café", `"caf\xe9"`},
+		{"bmp", "日本", `"\u65e5\u672c"`},
+		{"astral", "😀", `"\U0001f600"`},
+		{"invalid utf8", "\xff\xfe", `"\xff\xfe"`},
+		{"format", "%d%%\n", `"%d%%\n"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expect, Quote(tt.in)); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package python
+
+import "cape/interlang"
+
+// rangeFor 数え上げるだけのfor文をfor i in range(a, b, step):にする
+//
+//	for (i = a; i < b; i += step)
+//
+// 次の条件を満たさなければokはfalseになり、呼び出し元でwhile文に置き換える
+//   - 初期化がカウンタへの代入か定義
+//   - 条件がカウンタと不変な値の<、<=、>、>=
+//   - 更新がカウンタに0でない定数を足すか引くもので、条件と向きが合っている
+//   - 本体と条件でカウンタに代入しない
+//   - カウンタをこの形のループの外で使わない(ループを抜けた後の値がCと異なるため)
+func (c *converter) rangeFor(iNode *interlang.Node) (*Node, bool, error) {
+	iForField := iNode.GetField().(*interlang.ForField)
+	if iForField.Init == nil || iForField.Cond == nil || iForField.Loop == nil {
+		return nil, false, nil
+	}
+
+	counter, start, ok := rangeInit(iForField.Init)
+	if !ok {
+		return nil, false, nil
+	}
+	op, stop, ok := rangeCond(iForField.Cond, counter)
+	if !ok {
+		return nil, false, nil
+	}
+	step, ok := rangeStep(iForField.Loop, counter)
+	if !ok {
+		return nil, false, nil
+	}
+	switch op {
+	case interlang.Lt, interlang.Le:
+		if step < 0 {
+			return nil, false, nil
+		}
+	case interlang.Gt, interlang.Ge:
+		if step > 0 {
+			return nil, false, nil
+		}
+	}
+	// rangeは整数しか受け取らない
+	if !c.intExpr(identOf(iForField.Init)) || !c.intExpr(start) || !c.intExpr(stop) {
+		return nil, false, nil
+	}
+	if assigns(iForField.Block, counter) || !invariant(stop, iForField.Block) {
+		return nil, false, nil
+	}
+	if !c.onlyRangeCounter(counter) {
+		return nil, false, nil
+	}
+
+	target := c.ident(identOf(iForField.Init))
+	iter, err := c.rangeCall(iNode, op, start, stop, step)
+	if err != nil {
+		return nil, false, err
+	}
+	block, err := c.statement(iForField.Block)
+	if err != nil {
+		return nil, false, err
+	}
+	return newNode(iNode, ForIn, &ForInField{target, iter, block, iForField.Label}), true, nil
+}
+
+// identOf 代入か定義の左辺
+func identOf(iNode *interlang.Node) *interlang.Node {
+	switch iField := iNode.GetField().(type) {
+	case *interlang.VariableDefineField:
+		return iField.Ident
+	case *interlang.AssignField:
+		return iField.To
+	default:
+		return nil
+	}
+}
+
+// rangeInit 初期化からカウンタの名前と初期値を取り出す
+func rangeInit(iNode *interlang.Node) (string, *interlang.Node, bool) {
+	switch iField := iNode.GetField().(type) {
+	case *interlang.VariableDefineField:
+		return identName(iField.Ident), iField.Value, true
+	case *interlang.AssignField:
+		if iField.To.GetKind() != interlang.Ident {
+			return "", nil, false
+		}
+		return identName(iField.To), iField.Value, true
+	default:
+		return "", nil, false
+	}
+}
+
+// rangeCond 条件から比較演算子と終わりの値を取り出す
+func rangeCond(iNode *interlang.Node, counter string) (interlang.Operation, *interlang.Node, bool) {
+	iField, ok := iNode.GetField().(*interlang.BinaryField)
+	if !ok || !isIdent(iField.LHS, counter) {
+		return 0, nil, false
+	}
+	switch iField.Operation {
+	case interlang.Lt, interlang.Le, interlang.Gt, interlang.Ge:
+		return iField.Operation, iField.RHS, true
+	default:
+		return 0, nil, false
+	}
+}
+
+// rangeStep 更新式から増分を取り出す
+// i = i + c、i = c + i、i = i - cの形だけを認める
+func rangeStep(iNode *interlang.Node, counter string) (int, bool) {
+	iField, ok := iNode.GetField().(*interlang.AssignField)
+	if !ok || !isIdent(iField.To, counter) {
+		return 0, false
+	}
+	binaryField, ok := iField.Value.GetField().(*interlang.BinaryField)
+	if !ok {
+		return 0, false
+	}
+	var step int
+	switch binaryField.Operation {
+	case interlang.Add:
+		if c, ok := intConst(binaryField.RHS); ok && isIdent(binaryField.LHS, counter) {
+			step = c
+		} else if c, ok := intConst(binaryField.LHS); ok && isIdent(binaryField.RHS, counter) {
+			step = c
+		} else {
+			return 0, false
+		}
+	case interlang.Sub:
+		c, ok := intConst(binaryField.RHS)
+		if !ok || !isIdent(binaryField.LHS, counter) {
+			return 0, false
+		}
+		step = -c
+	default:
+		return 0, false
+	}
+	return step, step != 0
+}
+
+func isIdent(iNode *interlang.Node, name string) bool {
+	return iNode.GetKind() == interlang.Ident && identName(iNode) == name
+}
+
+// intConst 整数の定数
+func intConst(iNode *interlang.Node) (int, bool) {
+	switch iField := iNode.GetField().(type) {
+	case *interlang.LiteralField:
+		if iField.GetTType() != interlang.Integer {
+			return 0, false
+		}
+		return iField.I, true
+	case *interlang.UnaryField:
+		c, ok := intConst(iField.Value)
+		if !ok {
+			return 0, false
+		}
+		switch iField.Operation {
+		case interlang.Neg:
+			return -c, true
+		case interlang.Plus:
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// intExpr 式の型が整数か
+// 型の付いていない識別子は宣言の型で判断する
+func (c *converter) intExpr(iNode *interlang.Node) bool {
+	switch iField := iNode.GetField().(type) {
+	case *interlang.IdentField:
+		if iField.TType != nil {
+			return iField.TType == interlang.Integer
+		}
+		return c.declaredType(iField.S) == interlang.Integer
+	case interface{ GetTType() interlang.TType }:
+		return iField.GetTType() == interlang.Integer
+	default:
+		return false
+	}
+}
+
+// assigns iNodeの中でnameに代入するか、同じ名前の変数を宣言するか
+func assigns(iNode *interlang.Node, name string) bool {
+	locals := map[string]bool{}
+	assigned := map[string]bool{}
+	collectNames(iNode, locals, assigned)
+	return locals[name] || assigned[name]
+}
+
+// invariant 終わりの値がループの間変わらないか
+// rangeは終わりの値を一度しか評価しないので、本体で変数を書き換えたり
+// 関数が大域変数を書き換えたりする場合は認めない
+func invariant(stop, block *interlang.Node) bool {
+	hasIdent := false
+	ok := true
+	interlang.Walk(stop, func(n *interlang.Node) bool {
+		switch n.GetKind() {
+		case interlang.Call, interlang.Assign:
+			ok = false
+		case interlang.Ident:
+			hasIdent = true
+			if assigns(block, identName(n)) {
+				ok = false
+			}
+		}
+		return ok
+	})
+	if !ok || !hasIdent {
+		return ok
+	}
+	interlang.Walk(block, func(n *interlang.Node) bool {
+		if n.GetKind() == interlang.Call {
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+// onlyRangeCounter 変換中の関数で、nameを初期化から始まるfor文の中でしか使っていないか
+// 仮引数や大域変数はループの外から値が見えるので認めない
+func (c *converter) onlyRangeCounter(name string) bool {
+	used := false
+	walkIdents(c.function.Params, func(s string) {
+		if s == name {
+			used = true
+		}
+	})
+	if used {
+		return false
+	}
+	locals := map[string]bool{}
+	collectNames(c.function.Block, locals, map[string]bool{})
+	if !locals[name] {
+		return false
+	}
+
+	interlang.Walk(c.function.Block, func(n *interlang.Node) bool {
+		switch iField := n.GetField().(type) {
+		case *interlang.ForField:
+			if iField.Init != nil {
+				if counter, _, ok := rangeInit(iField.Init); ok && counter == name {
+					return false
+				}
+			}
+		case *interlang.VariableDeclareField:
+			return false
+		case *interlang.IdentField:
+			if iField.S == name {
+				used = true
+			}
+		}
+		return true
+	})
+	return !used
+}
+
+// rangeCall range(start, stop, step)の呼び出しを組み立てる
+// <=と>=は終わりの値を一つずらし、省略できる引数は省く
+func (c *converter) rangeCall(iNode *interlang.Node, op interlang.Operation, iStart, iStop *interlang.Node, step int) (*Node, error) {
+	start, err := c.expr(iStart)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := c.expr(iStop)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case interlang.Le:
+		stop = offset(iStop, stop, 1)
+	case interlang.Ge:
+		stop = offset(iStop, stop, -1)
+	}
+
+	var args []*Node
+	switch {
+	case step == 1 && isZero(start):
+		args = []*Node{stop}
+	case step == 1:
+		args = []*Node{start, stop}
+	default:
+		args = []*Node{start, stop, newNode(iNode, Literal, &LiteralField{TType: Integer, I: step})}
+	}
+	name := newNode(iNode, Ident, &IdentField{S: "range"})
+	return newNode(iNode, Call, &CallField{Integer, name, newNode(iNode, Multiple, &MultipleField{Values: args})}), nil
+}
+
+// offset 値にdを足す
+// 整数のリテラルならその場で計算する
+func offset(iNode *interlang.Node, node *Node, d int) *Node {
+	if node.GetKind() == Literal {
+		literalField := node.GetField().(*LiteralField)
+		if literalField.GetTType() == Integer {
+			return newNode(iNode, Literal, &LiteralField{TType: Integer, I: literalField.I + d})
+		}
+	}
+	if d < 0 {
+		return newNode(iNode, Binary, &BinaryField{Integer, Sub, node, newNode(iNode, Literal, &LiteralField{TType: Integer, I: -d})})
+	}
+	return newNode(iNode, Binary, &BinaryField{Integer, Add, node, newNode(iNode, Literal, &LiteralField{TType: Integer, I: d})})
+}
+
+func isZero(node *Node) bool {
+	if node.GetKind() != Literal {
+		return false
+	}
+	literalField := node.GetField().(*LiteralField)
+	return literalField.GetTType() == Integer && literalField.I == 0
+}
@@ -0,0 +1,152 @@
+package python
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestRangeFor(t *testing.T) {
+	inc := func(name string, d int) *interlang.Node {
+		return build.Assign(build.Id(name), build.Bin(build.Add, build.Id(name), build.IntLit(d)))
+	}
+	dec := func(name string, d int) *interlang.Node {
+		return build.Assign(build.Id(name), build.Bin(build.Sub, build.Id(name), build.IntLit(d)))
+	}
+	sum := build.Assign(build.Id("s"), build.Bin(build.Add, build.Id("s"), build.Id("i")))
+
+	tests := []struct {
+		name   string
+		stmts  []*interlang.Node
+		expect string
+	}{
+		{
+			"canonical",
+			[]*interlang.Node{
+				build.For(build.Define("i", build.Int, build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.IntLit(10)), inc("i", 1), build.Block(sum)),
+			},
+			"    for i in range(10):\n" +
+				"        s = s + i\n",
+		},
+		{
+			"le",
+			[]*interlang.Node{
+				build.For(build.Define("i", build.Int, build.IntLit(1)), build.Bin(build.Le, build.Id("i"), build.Id("n")), inc("i", 2), build.Block(sum)),
+			},
+			"    for i in range(1, n + 1, 2):\n" +
+				"        s = s + i\n",
+		},
+		{
+			"descending",
+			[]*interlang.Node{
+				build.Declare("i", build.Int),
+				build.For(build.Assign(build.Id("i"), build.Id("n")), build.Bin(build.Ge, build.Id("i"), build.IntLit(0)), dec("i", 1), build.Block(sum)),
+			},
+			"    for i in range(n, -1, -1):\n" +
+				"        s = s + i\n",
+		},
+		{
+			"labeled continue",
+			[]*interlang.Node{
+				build.Labeled("outer", build.For(build.Define("i", build.Int, build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.IntLit(3)), inc("i", 1), build.Block(
+					build.While(build.IntLit(1), build.Block(build.ContinueTo("outer"))),
+				))),
+			},
+			"    for i in range(3):\n" +
+				"        _continue_outer = False\n" +
+				"        while True:\n" +
+				"            _continue_outer = True\n" +
+				"            break\n" +
+				"        if _continue_outer:\n" +
+				"            continue\n",
+		},
+		{
+			"counter written in body",
+			[]*interlang.Node{
+				build.For(build.Define("i", build.Int, build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.IntLit(10)), inc("i", 1), build.Block(inc("i", 1))),
+			},
+			"    i = 0\n" +
+				"    while i < 10:\n" +
+				"        i = i + 1\n" +
+				"        i = i + 1\n",
+		},
+		{
+			"counter used after loop",
+			[]*interlang.Node{
+				build.Declare("i", build.Int),
+				build.For(build.Assign(build.Id("i"), build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.IntLit(10)), inc("i", 1), build.Block()),
+				build.Assign(build.Id("s"), build.Id("i")),
+			},
+			"    i = 0\n" +
+				"    while i < 10:\n" +
+				"        i = i + 1\n" +
+				"    s = i\n",
+		},
+		{
+			"bound written in body",
+			[]*interlang.Node{
+				build.For(build.Define("i", build.Int, build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.Id("n")), inc("i", 1), build.Block(dec("n", 1))),
+			},
+			"    i = 0\n" +
+				"    while i < n:\n" +
+				"        n = n - 1\n" +
+				"        i = i + 1\n",
+		},
+		{
+			"float counter",
+			[]*interlang.Node{
+				build.For(build.Define("x", build.Float, build.FloatLit(0.5)), build.Bin(build.Lt, build.Id("x"), build.IntLit(3)), inc("x", 1), build.Block()),
+			},
+			"    x = 0.5\n" +
+				"    while x < 3:\n" +
+				"        x = x + 1\n",
+		},
+		{
+			"float bound",
+			[]*interlang.Node{
+				build.For(build.Define("i", build.Int, build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.FloatLit(2.5)), inc("i", 1), build.Block()),
+			},
+			"    i = 0\n" +
+				"    while i < 2.5:\n" +
+				"        i = i + 1\n",
+		},
+		{
+			"wrong direction",
+			[]*interlang.Node{
+				build.For(build.Define("i", build.Int, build.IntLit(0)), build.Bin(build.Lt, build.Id("i"), build.IntLit(10)), dec("i", 1), build.Block(build.Break())),
+			},
+			"    i = 0\n" +
+				"    while i < 10:\n" +
+				"        break\n" +
+				"        i = i - 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts := append([]*interlang.Node{
+				build.Define("s", build.Int, build.IntLit(0)),
+				build.Define("n", build.Int, build.IntLit(5)),
+			}, tt.stmts...)
+			p := &interlang.Program{
+				Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(stmts...))},
+			}
+			nodes, err := ConvertProgramFromInterLang(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Gen(nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expect := "def main():\n" +
+				"    s = 0\n" +
+				"    n = 5\n" +
+				tt.expect +
+				"if __name__ == \"__main__\":\n    main()"
+			if diff := cmp.Diff(expect, got); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
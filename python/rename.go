@@ -0,0 +1,163 @@
+package python
+
+import (
+	"cape/interlang"
+	"fmt"
+	"sort"
+)
+
+// keywords pythonの予約語
+var keywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await",
+	"break", "class", "continue", "def", "del", "elif", "else", "except",
+	"finally", "for", "from", "global", "if", "import", "in", "is",
+	"lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try",
+	"while", "with", "yield",
+	// ソフトキーワード
+	"match", "case", "type",
+}
+
+// builtins 上書きすると生成したコードが壊れる組み込みの名前
+var builtins = []string{
+	"abs", "all", "any", "ascii", "bin", "bool", "breakpoint", "bytearray",
+	"bytes", "callable", "chr", "classmethod", "compile", "complex",
+	"delattr", "dict", "dir", "divmod", "enumerate", "eval", "exec",
+	"exit", "filter", "float", "format", "frozenset", "getattr", "globals",
+	"hasattr", "hash", "help", "hex", "id", "input", "int", "isinstance",
+	"issubclass", "iter", "len", "list", "locals", "map", "max",
+	"memoryview", "min", "next", "object", "oct", "open", "ord", "pow",
+	"print", "property", "quit", "range", "repr", "reversed", "round",
+	"set", "setattr", "slice", "sorted", "staticmethod", "str", "sum",
+	"super", "tuple", "vars", "zip",
+	"__name__", "__import__",
+}
+
+// reserved 大域変数などに使えない名前
+// importするモジュールの名前も含める
+func reserved(imports []*interlang.Import) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range keywords {
+		names[name] = true
+	}
+	for _, name := range builtins {
+		names[name] = true
+	}
+	for _, module := range headerModules {
+		if module != "" {
+			names[module] = true
+		}
+	}
+	// 生成したコードに置く補助関数
+	for _, h := range helpers {
+		names[h.name] = true
+	}
+	names[scanned] = true
+	for _, imp := range imports {
+		if module := headerModules[imp.Name]; module != "" {
+			names[module] = true
+		}
+	}
+	return names
+}
+
+// Rename 予約語と衝突したために付け替えた名前
+type Rename struct {
+	From string
+	To   string
+}
+
+// Renames プログラム中で宣言している名前のうち、pythonの予約語と衝突するものの付け替え方
+// 元の名前に"_"を付け、それも使われていれば"_2"、"_3"と番号を増やす
+// 結果は元の名前の順に並べる
+func Renames(p *interlang.Program) []Rename {
+	reservedNames := reserved(p.Imports)
+
+	declared := map[string]bool{}
+	used := map[string]bool{}
+	for _, iNode := range p.Nodes() {
+		declaredNames(iNode, declared)
+		walkIdents(iNode, func(name string) { used[name] = true })
+		loopFlags(iNode, reservedNames)
+	}
+
+	var names []string
+	for name := range declared {
+		if reservedNames[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var renames []Rename
+	for _, name := range names {
+		to := name + "_"
+		for i := 2; reservedNames[to] || used[to]; i++ {
+			to = fmt.Sprintf("%s_%d", name, i)
+		}
+		used[to] = true
+		renames = append(renames, Rename{From: name, To: to})
+	}
+	return renames
+}
+
+// declaredNames 関数、大域変数、局所変数、仮引数、型の名前を集める
+func declaredNames(iNode *interlang.Node, names map[string]bool) {
+	interlang.Walk(iNode, func(n *interlang.Node) bool {
+		switch iField := n.GetField().(type) {
+		case *interlang.TypeDefineField:
+			names[identName(iField.Ident)] = true
+		case *interlang.VariableDeclareField:
+			names[identName(iField.Ident)] = true
+		case *interlang.VariableDefineField:
+			names[identName(iField.Ident)] = true
+		case *interlang.FunctionDeclareField:
+			names[identName(iField.Ident)] = true
+			walkIdents(iField.Params, func(name string) { names[name] = true })
+		case *interlang.FunctionDefineField:
+			names[identName(iField.Ident)] = true
+			walkIdents(iField.Params, func(name string) { names[name] = true })
+		}
+		return true
+	})
+}
+
+// loopFlags ラベル付きのループを抜けるためにgenが使うフラグの名前を集める
+func loopFlags(iNode *interlang.Node, names map[string]bool) {
+	interlang.Walk(iNode, func(n *interlang.Node) bool {
+		var label string
+		switch iField := n.GetField().(type) {
+		case *interlang.WhileField:
+			label = iField.Label
+		case *interlang.ForField:
+			label = iField.Label
+		}
+		if label != "" {
+			names[breakFlagPrefix+label] = true
+			names[continueFlagPrefix+label] = true
+		}
+		return true
+	})
+}
+
+// walkIdents 全ての識別子の名前を辿る
+func walkIdents(iNode *interlang.Node, f func(name string)) {
+	interlang.Walk(iNode, func(n *interlang.Node) bool {
+		if iField, ok := n.GetField().(*interlang.IdentField); ok {
+			f(iField.S)
+		}
+		return true
+	})
+}
+
+// renamed 付け替え後の名前
+func (c *converter) renamed(name string) string {
+	if to, ok := c.renames[name]; ok {
+		return to
+	}
+	return name
+}
+
+// ident 中間言語の識別子を、予約語との衝突を避けた名前の識別子にする
+func (c *converter) ident(iNode *interlang.Node) *Node {
+	return newNode(iNode, Ident, &IdentField{S: c.renamed(identName(iNode))})
+}
@@ -0,0 +1,136 @@
+package python
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+func TestRenames(t *testing.T) {
+	p := &interlang.Program{
+		Imports: []*interlang.Import{{Name: "math.h"}},
+		Globals: []*interlang.Node{
+			build.Define("list", build.Int, build.IntLit(1)),
+			build.Define("math", build.Int, build.IntLit(2)),
+		},
+		Funcs: []*interlang.Node{
+			build.Func("print", build.Int, build.Params(), build.Block(
+				build.Define("None", build.Int, build.IntLit(3)),
+				// print_は既に使われているのでprint_2になる
+				build.Define("print_", build.Int, build.Id("None")),
+				build.Return(build.Id("print_")),
+			)),
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Assign(build.Id("list"), build.IntLit(4)),
+				build.Return(build.Bin(build.Add, build.Id("list"), build.Id("math"))),
+			)),
+		},
+	}
+
+	expectRenames := []Rename{
+		{From: "None", To: "None_"},
+		{From: "list", To: "list_"},
+		{From: "math", To: "math_"},
+		{From: "print", To: "print_2"},
+	}
+	if diff := cmp.Diff(expectRenames, Renames(p)); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+
+	nodes, err := ConvertProgramFromInterLang(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Gen(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "import math\n" +
+		"list_ = 1\n" +
+		"math_ = 2\n" +
+		"def print_2():\n" +
+		"    None_ = 3\n" +
+		"    print_ = None_\n" +
+		"    return print_\n" +
+		"def main():\n" +
+		"    global list_\n" +
+		"    list_ = 4\n" +
+		"    return list_ + math_\n" +
+		"if __name__ == \"__main__\":\n    main()"
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+// TestRenamesHelper 補助関数と同じ名前は付け替え、補助関数の呼び出しと区別する
+func TestRenamesHelper(t *testing.T) {
+	p := &interlang.Program{
+		Funcs: []*interlang.Node{
+			build.Func("_cdiv", build.Int, build.Params(build.Param("a", build.Int)), build.Block(
+				build.Return(build.Bin(build.Div, build.Id("a"), build.IntLit(-2))),
+			)),
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Return(build.Call("_cdiv", build.IntLit(7))),
+			)),
+		},
+	}
+	nodes, err := ConvertProgramFromInterLang(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Gen(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "def _cdiv(a, b):\n" +
+		"    q = abs(a) // abs(b)\n" +
+		"    return q if (a < 0) == (b < 0) else -q\n" +
+		"def _cdiv_(a):\n" +
+		"    return _cdiv(a, -2)\n" +
+		"def main():\n" +
+		"    return _cdiv_(7)\n" +
+		"if __name__ == \"__main__\":\n    main()"
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+// TestRenamesInput 入力の補助関数と、式の中のscanfが使う一時変数の名前も付け替える
+func TestRenamesInput(t *testing.T) {
+	p := &interlang.Program{
+		Funcs: []*interlang.Node{
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("_scanned", build.Int, build.IntLit(0)),
+				build.Define("_getchar", build.Int, build.IntLit(0)),
+				build.Return(build.Bin(build.Add, build.Id("_scanned"), build.Id("_getchar"))),
+			)),
+		},
+	}
+	expect := []Rename{
+		{From: "_getchar", To: "_getchar_"},
+		{From: "_scanned", To: "_scanned_"},
+	}
+	if diff := cmp.Diff(expect, Renames(p)); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+// TestRenamesLoopFlag ラベル付きのループを抜けるためのフラグと同じ名前も付け替える
+func TestRenamesLoopFlag(t *testing.T) {
+	p := &interlang.Program{
+		Funcs: []*interlang.Node{
+			build.Func("main", build.Int, build.Params(), build.Block(
+				build.Define("_break_outer", build.Int, build.IntLit(0)),
+				build.Labeled("outer", build.While(build.Id("_break_outer"), build.Block(
+					build.While(build.IntLit(1), build.Block(build.BreakTo("outer"))),
+				))),
+				build.Return(build.IntLit(0)),
+			)),
+		},
+	}
+	expect := []Rename{{From: "_break_outer", To: "_break_outer_"}}
+	if diff := cmp.Diff(expect, Renames(p)); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
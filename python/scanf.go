@@ -0,0 +1,213 @@
+package python
+
+import (
+	"cape/interlang"
+	"fmt"
+	"strings"
+)
+
+// scanTargets scanfの書式を確かめ、値を格納する変換指定の数を返す
+// 読み取りは補助関数の_scanfが書式を解釈して行う
+func scanTargets(s string) (int, error) {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(s) && s[i] == '%' {
+			continue
+		}
+		suppress := i < len(s) && s[i] == '*'
+		if suppress {
+			i++
+		}
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+		for i < len(s) && strings.IndexByte("hlLqjzt", s[i]) >= 0 {
+			i++
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("incomplete format: %q", s)
+		}
+		if strings.IndexByte("diuoxXfeEgGsc", s[i]) < 0 {
+			return 0, fmt.Errorf("unsupported format verb: %%%c", s[i])
+		}
+		if !suppress {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// scanTarget scanfの格納先の変数名
+// &xか、文字列を読み込む配列の名前だけを認める
+func scanTarget(node *Node) (string, error) {
+	if field, ok := node.GetField().(*UnaryField); ok && field.Operation == Addr {
+		node = field.Value
+	}
+	if node.GetKind() != Ident {
+		return "", fmt.Errorf("%v: unsupported scanf target", node.GetSpan())
+	}
+	return node.GetField().(*IdentField).S, nil
+}
+
+// genScanfCall _scanfの呼び出しと、戻り値の2番目以降を代入する変数名
+func (g *generator) genScanfCall(node *Node, args []*Node) (string, []string, error) {
+	if len(args) == 0 {
+		return "", nil, fmt.Errorf("%v: missing format", node.GetSpan())
+	}
+	literalField, ok := args[0].GetField().(*LiteralField)
+	if !ok || literalField.GetTType() != String {
+		return "", nil, fmt.Errorf("%v: the format of scanf must be a string literal", node.GetSpan())
+	}
+	n, err := scanTargets(literalField.S)
+	if err != nil {
+		return "", nil, fmt.Errorf("%v: %v", node.GetSpan(), err)
+	}
+	if n != len(args)-1 {
+		return "", nil, fmt.Errorf("%v: scanf format %q takes %d arguments but got %d", node.GetSpan(), literalField.S, n, len(args)-1)
+	}
+
+	var targets []string
+	for _, arg := range args[1:] {
+		target, err := scanTarget(arg)
+		if err != nil {
+			return "", nil, err
+		}
+		targets = append(targets, target)
+	}
+	// 読めなかった格納先の値は変えないので、今の値を渡しておく
+	call := g.useHelper("_scanf") + "(" + strings.Join(append([]string{Quote(literalField.S)}, targets...), ", ") + ")"
+	return call, targets, nil
+}
+
+// genScanfStmt 文としてのscanfは戻り値を格納先へ代入する
+//
+//	_, a, b = _scanf("%d %d", a, b)
+func (g *generator) genScanfStmt(node *Node, args []*Node) (string, error) {
+	call, targets, err := g.genScanfCall(node, args)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		return call, nil
+	}
+	return fmt.Sprintf("_, %s = %s", strings.Join(targets, ", "), call), nil
+}
+
+// scanned 式の中のscanfとfgetsの戻り値を一時的に置く変数
+const scanned = "_scanned"
+
+// genScanfExpr 読めた数を使うscanfは、格納先への代入を代入式で済ませて数だけを取り出す
+//
+//	[_scanned := _scanf("%d %d", a, b), a := _scanned[1], b := _scanned[2]][0][0]
+func (g *generator) genScanfExpr(node *Node, args []*Node) (string, error) {
+	call, targets, err := g.genScanfCall(node, args)
+	if err != nil {
+		return "", err
+	}
+	if len(targets) == 0 {
+		return call + "[0]", nil
+	}
+	if err := g.require(node, 3, 8, "assignment expression"); err != nil {
+		return "", err
+	}
+	items := []string{scanned + " := " + call}
+	for i, target := range targets {
+		items = append(items, fmt.Sprintf("%s := %s[%d]", target, scanned, i+1))
+	}
+	return "[" + strings.Join(items, ", ") + "][0][0]", nil
+}
+
+// genFgets fgets(buf, n, stdin)はbufへ一行を代入する
+// 入力が終わっていればCと同じくbufを変えないので、_fgetsに今の値を渡す
+// 戻り値を使う場合は代入式にする
+//
+//	_, buf = _fgets(n, buf)
+//	[_scanned := _fgets(n, buf), buf := _scanned[1]][0][0]
+func (g *generator) genFgets(node *Node, args []*Node, isExpr bool) (string, error) {
+	if len(args) != 3 {
+		return "", fmt.Errorf("%v: fgets requires three arguments", node.GetSpan())
+	}
+	if args[0].GetKind() != Ident {
+		return "", fmt.Errorf("%v: unsupported fgets buffer", node.GetSpan())
+	}
+	if args[2].GetKind() != Ident || args[2].GetField().(*IdentField).S != "stdin" {
+		return "", fmt.Errorf("%v: fgets supports only stdin", node.GetSpan())
+	}
+	buf := args[0].GetField().(*IdentField).S
+	size, err := g.genExpr(args[1])
+	if err != nil {
+		return "", err
+	}
+	call := fmt.Sprintf("%s(%s, %s)", g.useHelper("_fgets"), size, buf)
+	if isExpr {
+		if err := g.require(node, 3, 8, "assignment expression"); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%s := %s, %s := %s[1]][0][0]", scanned, call, buf, scanned), nil
+	}
+	return fmt.Sprintf("_, %s = %s", buf, call), nil
+}
+
+// stdioMacro stdio.hのマクロのうち、値に置き換えるもの
+func stdioMacro(iNode *interlang.Node) *Node {
+	switch identName(iNode) {
+	case "EOF":
+		return newNode(iNode, Literal, &LiteralField{TType: Integer, I: -1})
+	case "NULL":
+		return newNode(iNode, Literal, &LiteralField{TType: Null})
+	default:
+		return nil
+	}
+}
+
+// inputTargets scanfとfgetsで値を書き込む変数
+// global文を置くかを決めるために代入先として扱う
+func inputTargets(iField *interlang.CallField) []string {
+	if iField.Args == nil {
+		return nil
+	}
+	args := iField.Args.GetField().(*interlang.MultipleField).Values
+	var names []string
+	switch identName(iField.Ident) {
+	case "scanf":
+		for _, arg := range args[min(1, len(args)):] {
+			if unaryField, ok := arg.GetField().(*interlang.UnaryField); ok && unaryField.Operation == interlang.Addr {
+				arg = unaryField.Value
+			}
+			if arg.GetKind() == interlang.Ident {
+				names = append(names, identName(arg))
+			}
+		}
+	case "fgets":
+		if len(args) != 0 && args[0].GetKind() == interlang.Ident {
+			names = append(names, identName(args[0]))
+		}
+	}
+	return names
+}
+
+// inputDeclares scanfとfgetsの格納先のうち、初期値なしで宣言した変数の宣言
+// _scanfと_fgetsには格納先の今の値を渡すので、代入より先に読まれなくても初期化する
+func inputDeclares(iField *interlang.FunctionDefineField) []*interlang.Node {
+	targets := map[string]bool{}
+	interlang.Walk(iField.Block, func(n *interlang.Node) bool {
+		if callField, ok := n.GetField().(*interlang.CallField); ok {
+			for _, name := range inputTargets(callField) {
+				targets[name] = true
+			}
+		}
+		return true
+	})
+	var decls []*interlang.Node
+	interlang.Walk(iField.Block, func(n *interlang.Node) bool {
+		if declField, ok := n.GetField().(*interlang.VariableDeclareField); ok && targets[identName(declField.Ident)] {
+			decls = append(decls, n)
+		}
+		return true
+	})
+	return decls
+}
@@ -0,0 +1,177 @@
+package python
+
+import (
+	"cape/interlang"
+	"cape/interlang/build"
+	"github.com/google/go-cmp/cmp"
+	"strings"
+	"testing"
+)
+
+func TestGenScanf(t *testing.T) {
+	addr := func(name string) *interlang.Node {
+		return build.Un(build.Addr, build.Id(name))
+	}
+	tests := []struct {
+		name    string
+		stmts   []*interlang.Node
+		helpers []string
+		expect  string
+	}{
+		{
+			"statement",
+			[]*interlang.Node{
+				build.Declare("a", build.Int),
+				build.Declare("b", build.Int),
+				build.Call("scanf", build.Str("%d %d"), addr("a"), addr("b")),
+				build.Call("scanf", build.Str("%s"), build.Id("s")),
+				build.Call("scanf", build.Str("%*d")),
+			},
+			[]string{"_unread", "_getc", "_scan_accepts", "_scan_token", "_scan_value", "_scanf"},
+			"    a = 0\n" +
+				"    b = 0\n" +
+				"    _, a, b = _scanf(\"%d %d\", a, b)\n" +
+				"    _, s = _scanf(\"%s\", s)\n" +
+				"    _scanf(\"%*d\")\n",
+		},
+		{
+			"declared without initializer",
+			[]*interlang.Node{
+				build.Declare("a", build.Int),
+				build.Call("scanf", build.Str("%d"), addr("a")),
+				build.Call("printf", build.Str("%d\n"), build.Id("a")),
+			},
+			[]string{"_unread", "_getc", "_scan_accepts", "_scan_token", "_scan_value", "_scanf"},
+			"    a = 0\n" +
+				"    _, a = _scanf(\"%d\", a)\n" +
+				"    print(\"%d\" % (a,))\n",
+		},
+		{
+			"count checked",
+			[]*interlang.Node{
+				build.Declare("a", build.Int),
+				build.If(build.Bin(build.Ne, build.Call("scanf", build.Str("%d"), addr("a")), build.IntLit(1)), build.Block(build.Return(build.IntLit(1)))),
+				build.While(build.Bin(build.Ne, build.Call("scanf", build.Str("%lf,%c"), addr("f"), addr("c")), build.Id("EOF")), build.Block()),
+			},
+			[]string{"_unread", "_getc", "_scan_accepts", "_scan_token", "_scan_value", "_scanf"},
+			"    a = 0\n" +
+				"    if [_scanned := _scanf(\"%d\", a), a := _scanned[1]][0][0] != 1:\n" +
+				"        return 1\n" +
+				"    while [_scanned := _scanf(\"%lf,%c\", f, c), f := _scanned[1], c := _scanned[2]][0][0] != -1:\n" +
+				"        pass\n",
+		},
+		{
+			"getchar",
+			[]*interlang.Node{
+				build.Declare("c", build.Int),
+				build.While(build.Bin(build.Ne, build.Assign(build.Id("c"), build.Call("getchar")), build.Id("EOF")), build.Block(
+					build.Call("putchar", build.Id("c")),
+				)),
+			},
+			[]string{"_unread", "_getc", "_getchar"},
+			"    while (c := _getchar()) != -1:\n" +
+				"        print(chr(c), end=\"\")\n",
+		},
+		{
+			"fgets",
+			[]*interlang.Node{
+				build.Declare("s", build.String),
+				build.Call("fgets", build.Id("s"), build.IntLit(80), build.Id("stdin")),
+				build.While(build.Bin(build.Ne, build.Call("fgets", build.Id("s"), build.IntLit(80), build.Id("stdin")), build.Id("NULL")), build.Block(
+					build.Call("printf", build.Str("%s"), build.Id("s")),
+				)),
+			},
+			[]string{"_unread", "_getc", "_fgets"},
+			"    s = None\n" +
+				"    _, s = _fgets(80, s)\n" +
+				"    while [_scanned := _fgets(80, s), s := _scanned[1]][0][0] != None:\n" +
+				"        print(\"%s\" % (s,), end=\"\")\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &interlang.Program{
+				Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(tt.stmts...))},
+			}
+			nodes, err := ConvertProgramFromInterLang(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Gen(nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header, body, _ := strings.Cut(got, "def main():\n")
+			if !strings.HasPrefix(header, "import sys\n") {
+				t.Fatalf("missing import sys:\n%s", header)
+			}
+			var helpers []string
+			for _, l := range strings.Split(header, "\n") {
+				if name, ok := strings.CutPrefix(l, "def "); ok {
+					helpers = append(helpers, name[:strings.Index(name, "(")])
+				} else if name, _, ok := strings.Cut(l, " = "); ok && !strings.HasPrefix(l, " ") {
+					helpers = append(helpers, name)
+				}
+			}
+			if diff := cmp.Diff(tt.helpers, helpers); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+			expect := tt.expect + "if __name__ == \"__main__\":\n    main()"
+			if diff := cmp.Diff(expect, body); diff != "" {
+				t.Fatalf("%v", diff)
+			}
+		})
+	}
+}
+
+func TestGenScanfGlobal(t *testing.T) {
+	p := &interlang.Program{
+		Globals: []*interlang.Node{build.Declare("n", build.Int)},
+		Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(
+			build.Call("scanf", build.Str("%d"), build.Un(build.Addr, build.Id("n"))),
+		))},
+	}
+	nodes, err := ConvertProgramFromInterLang(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Gen(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body, _ := strings.Cut(got, "def main():\n")
+	expect := "    global n\n" +
+		"    _, n = _scanf(\"%d\", n)\n" +
+		"if __name__ == \"__main__\":\n    main()"
+	if diff := cmp.Diff(expect, body); diff != "" {
+		t.Fatalf("%v", diff)
+	}
+}
+
+func TestGenScanfError(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt *interlang.Node
+	}{
+		{"variable format", build.Call("scanf", build.Id("f"), build.Un(build.Addr, build.Id("a")))},
+		{"too few targets", build.Call("scanf", build.Str("%d %d"), build.Un(build.Addr, build.Id("a")))},
+		{"scanset", build.Call("scanf", build.Str("%[a-z]"), build.Id("s"))},
+		{"target is not a variable", build.Call("scanf", build.Str("%d"), build.Un(build.Addr, build.Bin(build.Add, build.Id("a"), build.IntLit(1))))},
+		{"fgets from file", build.Call("fgets", build.Id("s"), build.IntLit(80), build.Id("fp"))},
+		{"address-of outside scanf", build.Call("f", build.Un(build.Addr, build.Id("a")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &interlang.Program{
+				Funcs: []*interlang.Node{build.Func("main", build.Int, build.Params(), build.Block(tt.stmt))},
+			}
+			nodes, err := ConvertProgramFromInterLang(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Gen(nodes); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
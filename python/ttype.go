@@ -1,5 +1,10 @@
 package python
 
+import (
+	"strconv"
+	"strings"
+)
+
 type TType interface {
 	IsEqual(tt2 TType) bool
 }
@@ -12,16 +17,98 @@ const (
 	Integer
 	String
 	Bool
+	Float
 )
 
-func (tt TPrimitive) IsEqual(tt2 TType) bool { // TODO
-	_ = tt2
-	return false
+func (tt TPrimitive) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TPrimitive)
+	return ok && tt == other
 }
 
 type TTuple []TType
 
-func (tt TTuple) IsEqual(tt2 TType) bool { // TODO
-	_ = tt2
-	return false
+func (tt TTuple) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TTuple)
+	if !ok || len(tt) != len(other) {
+		return false
+	}
+	for i := range tt {
+		if tt[i] == nil || other[i] == nil {
+			if tt[i] != other[i] {
+				return false
+			}
+			continue
+		}
+		if !tt[i].IsEqual(other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// TList list[Elem]
+// Cのポインタと配列はリストにする
+type TList struct {
+	Elem TType
+}
+
+func (tt TList) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TList)
+	if !ok {
+		return false
+	}
+	if tt.Elem == nil || other.Elem == nil {
+		return tt.Elem == nil && other.Elem == nil
+	}
+	return tt.Elem.IsEqual(other.Elem)
+}
+
+// TClass クラス
+// Cの構造体は同じ名前のクラスにする
+type TClass struct {
+	Name string
+}
+
+func (tt TClass) IsEqual(tt2 TType) bool {
+	other, ok := tt2.(TClass)
+	return ok && tt.Name == other.Name
+}
+
+// typeHint 型ヒントとして書く型の名前
+// 分からない型は空文字列にする
+func typeHint(tt TType) string {
+	switch tt := tt.(type) {
+	case TPrimitive:
+		switch tt {
+		case Integer:
+			return "int"
+		case String:
+			return "str"
+		case Bool:
+			return "bool"
+		case Null:
+			return "None"
+		case Float:
+			return "float"
+		}
+	case TTuple:
+		var elems []string
+		for _, elem := range tt {
+			hint := typeHint(elem)
+			if hint == "" {
+				return "tuple"
+			}
+			elems = append(elems, hint)
+		}
+		return "tuple[" + strings.Join(elems, ", ") + "]"
+	case TList:
+		if hint := typeHint(tt.Elem); hint != "" && hint != "None" {
+			return "list[" + hint + "]"
+		}
+		return "list"
+	case TClass:
+		// 前方参照として文字列にしておけば、クラスを定義する前でも評価できる
+		return strconv.Quote(tt.Name)
+	}
+	return ""
 }